@@ -1,157 +1,201 @@
-package plugo
-
-import (
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"net/url"
-	"strings"
-)
-
-// Connection is a user-friendly interface to perform http responses
-type Connection interface {
-	// HTTP hRequest getter
-	Request() *http.Request
-
-	// HTTP Response getter
-	Response() *Response
-
-	// URL getter for http.Request.URL()
-	URL() *url.URL
-
-	// PathParams gets an slice of parameter values if they exists in the url
-	PathParams() []string
-
-	// Param gets the value of a parameter if it exists in the url
-	Param(key string) (value string, ok bool)
-
-	// HTML sends a new response in HTML format
-	HTML(code int, data string) error
-
-	// String sends a new response in plain text format
-	String(code int, data string, args ...any) error
-
-	// JSON sends a new response in JSON format automatically decoding the parameter 'data'
-	JSON(code int, data any) error
-
-	// JSONBlob sends a new response in JSON format
-	JSONBlob(code int, b []byte) error
-
-	// Blob sends a new response in any desired content-type
-	Blob(code int, contentType string, b []byte) error
-}
-
-type connectionImpl struct {
-	response *Response
-	request  *http.Request
-	pattern  string
-	path     string
-	params   []string
-}
-
-var _ Connection = &connectionImpl{}
-
-func newConnection(w http.ResponseWriter, r *http.Request) *connectionImpl {
-	pattern := r.Context().Value(MethodID("pattern")).(string)
-
-	return &connectionImpl{
-		response: NewResponse(w),
-		request:  r,
-		pattern:  pattern,
-		path:     cleanPath(r.URL.Path),
-		params:   parseParamKeysFromPattern(pattern),
-	}
-}
-
-func NewConnection(w http.ResponseWriter, r *http.Request) Connection {
-	return newConnection(w, r)
-}
-
-func (conn *connectionImpl) Request() *http.Request {
-	return conn.request
-}
-
-func (conn *connectionImpl) Response() *Response {
-	return conn.response
-}
-
-func (conn *connectionImpl) URL() *url.URL {
-	return conn.request.URL
-}
-
-func (conn *connectionImpl) PathParams() []string {
-	res := make([]string, 0)
-
-	for _, key := range conn.params {
-		pattMoves := strings.Split(strings.Split(conn.pattern, ":"+key)[0], "/")
-		if strings.HasSuffix(conn.pattern, "/") {
-			pattMoves = pattMoves[:len(pattMoves)-1]
-		}
-
-		depth := len(pattMoves) - 1
-
-		moves := strings.Split(conn.path, "/")
-		if strings.HasSuffix(conn.path, "/") {
-			moves = moves[:len(moves)-1]
-		}
-
-		if len(moves) >= depth {
-			res = append(res, moves[depth])
-		}
-	}
-
-	return res
-}
-
-func (conn *connectionImpl) Param(key string) (value string, ok bool) {
-	values := conn.PathParams()
-
-	for i, param := range conn.params {
-		if param == key {
-			value = values[i]
-			ok = true
-
-			return
-		}
-	}
-
-	return
-}
-
-func (conn *connectionImpl) HTML(code int, data string) error {
-	return conn.Blob(code, "text/html", []byte(data))
-}
-
-func (conn *connectionImpl) String(code int, data string, args ...any) error {
-	return conn.Blob(code, "text/plain", []byte(fmt.Sprintf(data, args...)))
-}
-
-func (conn *connectionImpl) JSON(code int, data any) error {
-	b, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-
-	return conn.JSONBlob(code, b)
-}
-
-func (conn *connectionImpl) JSONBlob(code int, b []byte) error {
-	return conn.Blob(code, "application/json", b)
-}
-
-func (c *connectionImpl) Blob(code int, contentType string, b []byte) error {
-	c.writeContentType(contentType)
-	if code != http.StatusOK {
-		c.response.WriteHeader(code)
-	}
-
-	_, err := c.response.Write(b)
-	return err
-}
-
-func (conn *connectionImpl) writeContentType(value string) {
-	header := conn.Response().Header()
-	if header.Get("Content-Type") == "" {
-		header.Set("Content-Type", value)
-	}
-}
+package plugo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Connection is a user-friendly interface to perform http responses
+type Connection interface {
+	// HTTP hRequest getter
+	Request() *http.Request
+
+	// HTTP Response getter
+	Response() *Response
+
+	// URL getter for http.Request.URL()
+	URL() *url.URL
+
+	// PathParams gets a copy of the parameter values if they exists in the url
+	PathParams() []string
+
+	// Param gets the value of a parameter if it exists in the url
+	Param(key string) (value string, ok bool)
+
+	// ParamInt gets the value of a parameter with the int type constraint, or parses it if it has none
+	ParamInt(key string) (value int, ok bool)
+
+	// ParamUint gets the value of a parameter with the uint type constraint, or parses it if it has none
+	ParamUint(key string) (value uint, ok bool)
+
+	// ParamFloat gets the value of a parameter with the float type constraint, or parses it if it has none
+	ParamFloat(key string) (value float64, ok bool)
+
+	// ParamBool gets the value of a parameter with the bool type constraint, or parses it if it has none
+	ParamBool(key string) (value bool, ok bool)
+
+	// ParamUUID gets the value of a parameter with the uuid type constraint, or parses it if it has none
+	ParamUUID(key string) (value UUID, ok bool)
+
+	// ParamDate gets the value of a parameter with the date type constraint, or parses it if it has none
+	ParamDate(key string) (value time.Time, ok bool)
+
+	// ParamValue gets the value of a parameter parsed by its type constraint, including custom types
+	ParamValue(key string) (value any, ok bool)
+
+	// HTML sends a new response in HTML format
+	HTML(code int, data string) error
+
+	// String sends a new response in plain text format
+	String(code int, data string, args ...any) error
+
+	// JSON sends a new response in JSON format automatically decoding the parameter 'data'
+	JSON(code int, data any) error
+
+	// JSONBlob sends a new response in JSON format
+	JSONBlob(code int, b []byte) error
+
+	// Blob sends a new response in any desired content-type
+	Blob(code int, contentType string, b []byte) error
+}
+
+type connectionImpl struct {
+	response *Response
+	request  *http.Request
+	route    *RoutingContext
+}
+
+var _ Connection = &connectionImpl{}
+
+func newConnection(w http.ResponseWriter, r *http.Request) *connectionImpl {
+	route := RouteContext(r.Context())
+	if route == nil {
+		route = &RoutingContext{}
+	}
+
+	return &connectionImpl{
+		response: NewResponse(w),
+		request:  r,
+		route:    route,
+	}
+}
+
+func NewConnection(w http.ResponseWriter, r *http.Request) Connection {
+	return newConnection(w, r)
+}
+
+func (conn *connectionImpl) Request() *http.Request {
+	return conn.request
+}
+
+func (conn *connectionImpl) Response() *Response {
+	return conn.response
+}
+
+func (conn *connectionImpl) URL() *url.URL {
+	return conn.request.URL
+}
+
+func (conn *connectionImpl) PathParams() []string {
+	// the values are reused by other requests once the router returns
+	return append([]string(nil), conn.route.values...)
+}
+
+func (conn *connectionImpl) Param(key string) (value string, ok bool) {
+	return conn.route.Params.Get(key)
+}
+
+func (conn *connectionImpl) ParamInt(key string) (int, bool) {
+	value, ok := conn.paramAs(key, parseIntParam).(int)
+	return value, ok
+}
+
+func (conn *connectionImpl) ParamUint(key string) (uint, bool) {
+	value, ok := conn.paramAs(key, parseUintParam).(uint)
+	return value, ok
+}
+
+func (conn *connectionImpl) ParamFloat(key string) (float64, bool) {
+	value, ok := conn.paramAs(key, parseFloatParam).(float64)
+	return value, ok
+}
+
+func (conn *connectionImpl) ParamBool(key string) (bool, bool) {
+	value, ok := conn.paramAs(key, parseBoolParam).(bool)
+	return value, ok
+}
+
+func (conn *connectionImpl) ParamUUID(key string) (UUID, bool) {
+	value, ok := conn.paramAs(key, parseUUIDParam).(UUID)
+	return value, ok
+}
+
+func (conn *connectionImpl) ParamDate(key string) (time.Time, bool) {
+	value, ok := conn.paramAs(key, parseDateParam).(time.Time)
+	return value, ok
+}
+
+func (conn *connectionImpl) ParamValue(key string) (any, bool) {
+	return conn.route.parsedParam(key)
+}
+
+// paramAs returns the parsed value of a parameter, parsing it with parse if
+// it has no type constraint. It returns nil if the value is not valid.
+func (conn *connectionImpl) paramAs(key string, parse ParamType) any {
+	if value, ok := conn.route.parsedParam(key); ok {
+		return value
+	}
+
+	raw, ok := conn.Param(key)
+	if !ok {
+		return nil
+	}
+
+	value, ok := parse(raw)
+	if !ok {
+		return nil
+	}
+
+	return value
+}
+
+func (conn *connectionImpl) HTML(code int, data string) error {
+	return conn.Blob(code, "text/html", []byte(data))
+}
+
+func (conn *connectionImpl) String(code int, data string, args ...any) error {
+	return conn.Blob(code, "text/plain", []byte(fmt.Sprintf(data, args...)))
+}
+
+func (conn *connectionImpl) JSON(code int, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return conn.JSONBlob(code, b)
+}
+
+func (conn *connectionImpl) JSONBlob(code int, b []byte) error {
+	return conn.Blob(code, "application/json", b)
+}
+
+func (c *connectionImpl) Blob(code int, contentType string, b []byte) error {
+	c.writeContentType(contentType)
+	if code != http.StatusOK {
+		c.response.WriteHeader(code)
+	}
+
+	_, err := c.response.Write(b)
+	return err
+}
+
+func (conn *connectionImpl) writeContentType(value string) {
+	header := conn.Response().Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", value)
+	}
+}
@@ -0,0 +1,121 @@
+package plugo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConnectionParams(t *testing.T) {
+	var tests = []struct {
+		name    string
+		pattern string
+		path    string
+		want    Params
+	}{
+		{"one param", "/hello/:world", "/hello/plugo", Params{{"world", "plugo"}}},
+		{"single character", "/points/:x/:y", "/points/1/2", Params{{"x", "1"}, {"y", "2"}}},
+		{"trailing slash", "/users/:id/posts/:post/", "/users/7/posts/9/", Params{{"id", "7"}, {"post", "9"}}},
+		{"between regexp", "/codes/{[0-9]+}/:code", "/codes/12/abc", Params{{"code", "abc"}}},
+		{"named regexp", "/codes/{id:[0-9]+}/:code", "/codes/12/abc", Params{{"id", "12"}, {"code", "abc"}}},
+	}
+
+	for _, test := range tests {
+		var got Params
+		var values []string
+
+		router := New()
+		router.Get(test.pattern, func(w http.ResponseWriter, r *http.Request) {
+			conn := NewConnection(w, r)
+			values = append(values, conn.PathParams()...)
+
+			for _, param := range test.want {
+				value, ok := conn.Param(param.Key)
+				if ok {
+					got = append(got, Param{param.Key, value})
+				}
+			}
+		})
+
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if len(got) != len(test.want) || len(values) != len(test.want) {
+			t.Errorf("%s got params %v (%v) want %v", test.name, got, values, test.want)
+			continue
+		}
+
+		for i := range test.want {
+			if got[i] != test.want[i] || values[i] != test.want[i].Value {
+				t.Errorf("%s got param %v want %v", test.name, got[i], test.want[i])
+			}
+		}
+	}
+}
+
+func TestConnectionParamsAllocs(t *testing.T) {
+	router := New()
+	router.Get("/users/:id/posts/:post", func(w http.ResponseWriter, r *http.Request) {
+		conn := NewConnection(w, r)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			conn.Param("post")
+		})
+
+		if allocs != 0 {
+			t.Errorf("got %v allocations looking up params want 0", allocs)
+		}
+
+		// the values are copied, as the routing context is reused by other requests
+		values := conn.PathParams()
+		values[0] = "changed"
+		if id, _ := conn.Param("id"); id != "1" || conn.PathParams()[0] != "1" {
+			t.Errorf("got param id '%s' after changing the values of PathParams", id)
+		}
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1/posts/2", nil))
+}
+
+func BenchmarkConnectionParam(b *testing.B) {
+	router := New()
+	router.Get("/users/:id/posts/:post/comments/:comment", func(w http.ResponseWriter, r *http.Request) {
+		conn := NewConnection(w, r)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			conn.Param("comment")
+		}
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1/posts/2/comments/3", nil))
+}
+
+func BenchmarkConnectionPathParams(b *testing.B) {
+	router := New()
+	router.Get("/users/:id/posts/:post/comments/:comment", func(w http.ResponseWriter, r *http.Request) {
+		conn := NewConnection(w, r)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			conn.PathParams()
+		}
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1/posts/2/comments/3", nil))
+}
+
+func BenchmarkRouterParams(b *testing.B) {
+	router := New()
+	router.Get("/users/:id/posts/:post/comments/:comment", func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/users/1/posts/2/comments/3", nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(w, r)
+	}
+}
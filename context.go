@@ -0,0 +1,175 @@
+package plugo
+
+import (
+	"context"
+	"regexp"
+)
+
+// contextKey is the type of the keys used to store plugo values in a request context.
+type contextKey struct {
+	name string
+}
+
+var routeContextKey = &contextKey{"route"}
+
+// RoutingContext keeps the routing data of a request matched by a Router.
+// The router reuses it for other requests once it returns, so it and the
+// Connection of the request are only valid until the handler returns, unless
+// the request context was created with WithRouteContext. Copy the values read
+// after that, like in goroutines started by the handler.
+type RoutingContext struct {
+	// pattern of the matched route as it was registered, with its optional parts
+	Pattern string
+
+	// name of the matched route, empty if it has not been named
+	Name string
+
+	// parameters extracted from the request path
+	Params Params
+
+	// values attached to the matched route with Route.Meta
+	Metadata map[string]any
+
+	// methods with a route for the request path, set when none of them is the
+	// request method
+	AllowedMethods []string
+
+	// values of Params, in the same order
+	values []string
+
+	// values of Params parsed by their type constraint, in the same order
+	parsed []any
+
+	// values of the parametric segments collected while matching the path
+	matches []string
+
+	// values of matches parsed by their type constraint
+	parsedMatches []any
+
+	// segments of the request path
+	moves []string
+
+	// unescaped segments of the request path, if the router uses the escaped path
+	unescaped []string
+}
+
+// Param is a single parameter extracted from the request path.
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params is a list of parameters in the order they appear in the route pattern.
+type Params []Param
+
+// Get returns the value of the first parameter with the given key.
+func (ps Params) Get(key string) (value string, ok bool) {
+	for _, param := range ps {
+		if param.Key == key {
+			return param.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// RouteContext returns the routing data of a request context, or nil if the
+// request has not been matched by a Router. It is only valid until the
+// handler returns, see RoutingContext.
+func RouteContext(ctx context.Context) *RoutingContext {
+	rctx, _ := ctx.Value(routeContextKey).(*RoutingContext)
+	return rctx
+}
+
+// WithRouteContext returns a copy of ctx with an empty RoutingContext that the
+// routers serving the request will fill, so outer handlers can read the
+// matched route once the request has been served. ctx is returned unchanged
+// if it already has a RoutingContext.
+func WithRouteContext(ctx context.Context) context.Context {
+	if RouteContext(ctx) != nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, routeContextKey, &RoutingContext{})
+}
+
+// reset clears the context keeping the allocated slices to be reused.
+func (rc *RoutingContext) reset() {
+	rc.Pattern = ""
+	rc.Name = ""
+	rc.Params = rc.Params[:0]
+	rc.Metadata = nil
+	rc.AllowedMethods = rc.AllowedMethods[:0]
+	rc.values = rc.values[:0]
+	rc.parsed = rc.parsed[:0]
+	rc.matches = rc.matches[:0]
+	rc.parsedMatches = rc.parsedMatches[:0]
+	rc.moves = rc.moves[:0]
+	rc.unescaped = rc.unescaped[:0]
+}
+
+// parsedParam returns the value of the first parameter with the given key parsed by its type constraint.
+func (rc *RoutingContext) parsedParam(key string) (value any, ok bool) {
+	for i, param := range rc.Params {
+		if param.Key == key {
+			if i < len(rc.parsed) && rc.parsed[i] != nil {
+				return rc.parsed[i], true
+			}
+
+			return nil, false
+		}
+	}
+
+	return nil, false
+}
+
+// push appends a value collected while matching and its parsed form.
+func (rc *RoutingContext) push(value string, parsed any) {
+	rc.matches = append(rc.matches, value)
+	rc.parsedMatches = append(rc.parsedMatches, parsed)
+}
+
+// pop drops the values collected after the first n, when the search backtracks.
+func (rc *RoutingContext) pop(n int) {
+	rc.matches = rc.matches[:n]
+	rc.parsedMatches = rc.parsedMatches[:n]
+}
+
+// addGroups appends the named capture groups of the values matched by regexp
+// segments to Params, using groups aligned with the collected values.
+func (rc *RoutingContext) addGroups(groups []*regexp.Regexp) {
+	for i, rex := range groups {
+		if rex == nil || i >= len(rc.matches) {
+			continue
+		}
+
+		submatches := rex.FindStringSubmatch(rc.matches[i])
+		for j, name := range rex.SubexpNames() {
+			if name == "" || j >= len(submatches) {
+				continue
+			}
+
+			rc.Params = append(rc.Params, Param{Key: name, Value: submatches[j]})
+			rc.values = append(rc.values, submatches[j])
+			rc.parsed = append(rc.parsed, nil)
+		}
+	}
+}
+
+// addParams appends the values collected while matching to Params, using keys
+// in order and skipping unnamed segments. Params of outer routers are kept.
+func (rc *RoutingContext) addParams(keys []string) {
+	for i, value := range rc.matches {
+		if i >= len(keys) {
+			break
+		}
+
+		if keys[i] == "" {
+			continue
+		}
+
+		rc.Params = append(rc.Params, Param{Key: keys[i], Value: value})
+		rc.values = append(rc.values, value)
+		rc.parsed = append(rc.parsed, rc.parsedMatches[i])
+	}
+}
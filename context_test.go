@@ -0,0 +1,79 @@
+package plugo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteContext(t *testing.T) {
+	type key string
+
+	var inner *RoutingContext
+	var value any
+
+	// the pooled routing context is only read while the request is served
+	var pattern, name, id string
+	var auth any
+
+	router := New()
+	router.Get("/users/:id/posts/:post", func(w http.ResponseWriter, r *http.Request) {
+		rctx := RouteContext(r.Context())
+		pattern, name, auth = rctx.Pattern, rctx.Name, rctx.Metadata["auth"]
+		id, _ = rctx.Params.Get("id")
+		value = r.Context().Value(key("user"))
+	}).Name("post.show").Meta("auth", true)
+
+	sub := New()
+	sub.Get("/files/:file", func(w http.ResponseWriter, r *http.Request) {
+		inner = RouteContext(r.Context())
+	})
+	router.Mount("/teams/:team", sub)
+
+	t.Run("preserves the request context", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), key("user"), "alice")
+		req := httptest.NewRequest(http.MethodGet, "/users/1/posts/2", nil).WithContext(ctx)
+
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if value != "alice" {
+			t.Errorf("got context value %v want alice", value)
+		}
+
+		if pattern != "/users/:id/posts/:post" || name != "post.show" {
+			t.Fatalf("unexpected routing context pattern '%s' name '%s'", pattern, name)
+		}
+
+		if auth != true {
+			t.Errorf("got metadata auth %v", auth)
+		}
+
+		if id != "1" {
+			t.Errorf("got param id '%s' want '1'", id)
+		}
+	})
+
+	t.Run("outer handlers read the route", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/teams/red/files/a.txt", nil)
+		req = req.WithContext(WithRouteContext(req.Context()))
+
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		rctx := RouteContext(req.Context())
+		if rctx != inner || rctx.Pattern != "/files/:file" {
+			t.Fatalf("outer routing context not filled %+v", rctx)
+		}
+
+		var want = Params{{"team", "red"}, {"file", "a.txt"}}
+		if len(rctx.Params) != len(want) {
+			t.Fatalf("got params %v want %v", rctx.Params, want)
+		}
+
+		for i := range want {
+			if rctx.Params[i] != want[i] {
+				t.Errorf("got param %v want %v", rctx.Params[i], want[i])
+			}
+		}
+	})
+}
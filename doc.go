@@ -1,19 +1,31 @@
-/*
-Plugo is a package to easily write a HTTP request router for your backend.
-
-You can write the following example of hello world to test:
-
-	func main() {
-		router := plugo.New()
-
-		router.Get("/", func(w http.ResponseWriter, r *http.Request) {
-			conn := plugo.NewConnection(w, r)
-
-			conn.String(http.StatusOK, "Hello, Plugo World!")
-		})
-
-		fmt.Println("Server running at http://localhost:8080/ - Press CTRL+C to exit")
-		log.Fatal(http.ListenAndServe(":8080", router))
-	}
-*/
-package plugo
+/*
+Plugo is a package to easily write a HTTP request router for your backend.
+
+You can write the following example of hello world to test:
+
+	func main() {
+		router := plugo.New()
+
+		router.Get("/", func(w http.ResponseWriter, r *http.Request) {
+			conn := plugo.NewConnection(w, r)
+
+			conn.String(http.StatusOK, "Hello, Plugo World!")
+		})
+
+		fmt.Println("Server running at http://localhost:8080/ - Press CTRL+C to exit")
+		log.Fatal(http.ListenAndServe(":8080", router))
+	}
+
+When several routes match a request path, segments are tried in this order of
+priority: static (/users/new), composite (/:name.:ext), regexp (/{id:[0-9]+}),
+typed parametric (/:id<int>), parametric (/:id) and catch all (/*path).
+If the more specific segment leads to no route for the request method, the
+next one is tried, so /users/new/edit is served by /users/:id/edit even if
+/users/new is registered.
+
+A colon followed by a letter starts a parameter anywhere in a segment, like
+/v:major or /:name.:ext, so a literal colon is escaped with a backslash:
+
+	router.Get(`/v1/books\:batchGet`, batchGetBooks)
+*/
+package plugo
@@ -1,23 +1,116 @@
-package plugo
-
-import "net/http"
-
-// HandlerFunc type to handle http request
-type HandlerFunc func(conn Connection) error
-
-// endpoints is a mapping of http method constants to handlers
-type endpoints map[MethodID]*endpoint
-
-type endpoint struct {
-	handler http.Handler
-	pattern string
-}
-
-func (e endpoints) Value(method MethodID) *endpoint {
-	mh, ok := e[method]
-	if !ok {
-		return nil
-	}
-
-	return mh
-}
+package plugo
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// HandlerFunc type to handle http request
+type HandlerFunc func(conn Connection) error
+
+// endpoints is a mapping of http method constants to handlers, the ones with
+// matchers first and the one without matchers, if any, last
+type endpoints map[MethodID][]*endpoint
+
+type endpoint struct {
+	handler http.Handler
+
+	// pattern of the endpoint, without optional parts
+	pattern string
+
+	// pattern of the route as it was registered, with its optional parts
+	routePattern string
+
+	// method of the endpoint, empty for mounts
+	method MethodID
+
+	// router or group that registered the endpoint
+	router *Router
+
+	// middlewares of the route, executed after the ones of its router
+	middlewares []MiddlewareFunc
+
+	// keys of the parametric and regexp segments of the pattern, in order
+	paramKeys []string
+
+	// regexp of the segments with named capture groups, aligned with paramKeys
+	groups []*regexp.Regexp
+
+	// name of the route
+	name string
+
+	// user defined data of the route
+	metadata map[string]any
+
+	// conditions to serve a request, set by the router with When
+	matchers []Matcher
+
+	// API version of the route, empty if it is not selected by the requested version
+	version string
+
+	// handler given to the router, described by Walk
+	source http.Handler
+}
+
+// Value returns the endpoint of the method without matchers.
+func (e endpoints) Value(method MethodID) *endpoint {
+	list := e[method]
+	if len(list) == 0 || len(list[len(list)-1].matchers) > 0 {
+		return nil
+	}
+
+	return list[len(list)-1]
+}
+
+// match returns the first endpoint of the method whose matchers accept r.
+func (e endpoints) match(method MethodID, r *http.Request) *endpoint {
+	for _, endp := range e[method] {
+		if endp.accepts(r) {
+			return endp
+		}
+	}
+
+	return nil
+}
+
+// versioned reports whether the method has endpoints selected by the requested version.
+func (e endpoints) versioned(method MethodID) bool {
+	for _, endp := range e[method] {
+		if endp.version != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// add registers endp in its method, keeping the endpoint without matchers last.
+// An endpoint without matchers replaces the existing one.
+func (e endpoints) add(endp *endpoint) {
+	list := e[endp.method]
+	last := len(list) - 1
+
+	switch {
+	case last >= 0 && len(list[last].matchers) == 0 && len(endp.matchers) == 0:
+		list[last] = endp
+
+	case last >= 0 && len(list[last].matchers) == 0:
+		list = append(list[:last], endp, list[last])
+
+	default:
+		list = append(list, endp)
+	}
+
+	e[endp.method] = list
+}
+
+// accepts reports whether all the matchers of the endpoint accept r.
+func (endp *endpoint) accepts(r *http.Request) bool {
+	for _, matcher := range endp.matchers {
+		if !matcher(r) {
+			return false
+		}
+	}
+
+	return true
+}
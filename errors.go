@@ -1,13 +1,138 @@
-package plugo
-
-import (
-	"errors"
-)
-
-var ErrMethodNotAllowed = errors.New("method not allowed for http request")
-
-var ErrHandleEmptyPattern = errors.New("could not handle an empty pattern")
-
-var ErrCreateEmptyNode = errors.New("could not create a new node with an empty pattern")
-
-var ErrPatternNotCompile = errors.New("could not compile pattern to a valid regular expression")
+package plugo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var ErrMethodNotAllowed = errors.New("method not allowed for http request")
+
+var ErrHandleEmptyPattern = errors.New("could not handle an empty pattern")
+
+var ErrCreateEmptyNode = errors.New("could not create a new node with an empty pattern")
+
+var ErrPatternNotCompile = errors.New("could not compile pattern to a valid regular expression")
+
+var ErrMountNilHandler = errors.New("could not mount a nil handler")
+
+var ErrDuplicateRouteName = errors.New("a route with the same name is already registered")
+
+var ErrRouteNameNotFound = errors.New("could not find a route with the given name")
+
+var ErrMissingParam = errors.New("missing value for a route parameter")
+
+var ErrInvalidParam = errors.New("value does not match the route parameter")
+
+var ErrTooManyParams = errors.New("too many values for the route parameters")
+
+var ErrUnknownParamType = errors.New("unknown type constraint for a route parameter")
+
+var ErrInvalidParamType = errors.New("invalid type constraint for route parameters")
+
+var ErrUnbalancedOptional = errors.New("unbalanced parentheses in the optional parts of a pattern")
+
+var ErrDuplicateRoute = errors.New("a route with the same method and pattern is already registered")
+
+var ErrAmbiguousParam = errors.New("parameters with different names at the same position of a pattern")
+
+var ErrUnreachableRoute = errors.New("route can not be matched by any request")
+
+var ErrInvalidMethod = errors.New("invalid name for an http method")
+
+var ErrInvalidHost = errors.New("invalid host pattern")
+
+// RouteError represents an error registering a route, wrapping one of the Err* values.
+type RouteError struct {
+	// method of the route, empty for mounts
+	Method MethodID
+
+	// pattern of the route
+	Pattern string
+
+	// segment of the pattern that caused the error, empty if the error is not
+	// related to a single segment
+	Segment string
+
+	// byte offset of Segment in Pattern, or -1
+	Offset int
+
+	// cause of the error
+	Err error
+}
+
+func (re *RouteError) Error() string {
+	if re.Segment != "" {
+		return fmt.Sprintf("method=%s, pattern=%s, segment=%s, offset=%d, error=%v", re.Method, re.Pattern, re.Segment, re.Offset, re.Err)
+	}
+
+	return fmt.Sprintf("method=%s, pattern=%s, error=%v", re.Method, re.Pattern, re.Err)
+}
+
+// Unwrap returns the cause of the error.
+func (re *RouteError) Unwrap() error {
+	return re.Err
+}
+
+// HTTPError represents an error with an HTTP status code that can be returned
+// by a HandlerFunc to be sent to the client by the ErrorHandler.
+type HTTPError struct {
+	// HTTP status code
+	Code int
+
+	// public message sent to the client
+	Message string
+
+	// internal cause of the error, never sent to the client
+	Internal error
+
+	// headers added to the response
+	Header http.Header
+}
+
+// NewHTTPError creates a new HTTPError, using the status text of code as
+// message when it is empty.
+func NewHTTPError(code int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+
+	return &HTTPError{
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (he *HTTPError) Error() string {
+	if he.Internal != nil {
+		return fmt.Sprintf("code=%d, message=%s, internal=%v", he.Code, he.Message, he.Internal)
+	}
+
+	return fmt.Sprintf("code=%d, message=%s", he.Code, he.Message)
+}
+
+// Unwrap returns the internal cause of the error.
+func (he *HTTPError) Unwrap() error {
+	return he.Internal
+}
+
+// WithInternal returns a copy of the error with the given internal cause.
+func (he *HTTPError) WithInternal(err error) *HTTPError {
+	c := *he
+	c.Internal = err
+
+	return &c
+}
+
+// WithHeader returns a copy of the error that adds the header to the response.
+func (he *HTTPError) WithHeader(key, value string) *HTTPError {
+	c := *he
+	c.Header = he.Header.Clone()
+	if c.Header == nil {
+		c.Header = make(http.Header)
+	}
+
+	c.Header.Add(key, value)
+
+	return &c
+}
@@ -0,0 +1,89 @@
+package plugo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorHandler(t *testing.T) {
+	errDatabase := errors.New("database is down")
+
+	router := New()
+	router.GetC("/ok", func(conn Connection) error {
+		return conn.String(http.StatusOK, "ok")
+	})
+	router.GetC("/users/:id", func(conn Connection) error {
+		return NewHTTPError(http.StatusNotFound, "user not found").WithHeader("X-Reason", "missing")
+	})
+	router.GetC("/internal", func(conn Connection) error {
+		return NewHTTPError(http.StatusServiceUnavailable, "").WithInternal(errDatabase)
+	})
+	router.GetC("/plain", func(conn Connection) error {
+		return errDatabase
+	})
+	router.GetC("/no-code", func(conn Connection) error {
+		return &HTTPError{Message: "x"}
+	})
+	router.GetC("/invalid-code", func(conn Connection) error {
+		return NewHTTPError(0, "")
+	})
+	router.GetC("/written", func(conn Connection) error {
+		conn.String(http.StatusAccepted, "accepted")
+		return errDatabase
+	})
+
+	var tests = []struct {
+		name   string
+		path   string
+		code   int
+		body   string
+		header string
+	}{
+		{"no error", "/ok", http.StatusOK, "ok", ""},
+		{"http error", "/users/1", http.StatusNotFound, "user not found", "missing"},
+		{"default message", "/internal", http.StatusServiceUnavailable, "Service Unavailable", ""},
+		{"plain error", "/plain", http.StatusInternalServerError, "Internal Server Error", ""},
+		{"missing code", "/no-code", http.StatusInternalServerError, "x", ""},
+		{"invalid code", "/invalid-code", http.StatusInternalServerError, "Internal Server Error", ""},
+		{"already written", "/written", http.StatusAccepted, "accepted", ""},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if w.Code != test.code {
+			t.Errorf("%s got code %d want %d", test.name, w.Code, test.code)
+		}
+
+		if w.Body.String() != test.body {
+			t.Errorf("%s got body '%s' want '%s'", test.name, w.Body.String(), test.body)
+		}
+
+		if got := w.Header().Get("X-Reason"); got != test.header {
+			t.Errorf("%s got header '%s' want '%s'", test.name, got, test.header)
+		}
+	}
+
+	t.Run("custom error handler", func(t *testing.T) {
+		var got error
+
+		router.ErrorHandler = func(conn Connection, err error) {
+			got = err
+			conn.JSON(http.StatusTeapot, map[string]string{"error": err.Error()})
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/internal", nil))
+
+		if !errors.Is(got, errDatabase) {
+			t.Errorf("got error %v want %v", got, errDatabase)
+		}
+
+		if w.Code != http.StatusTeapot {
+			t.Errorf("got code %d want %d", w.Code, http.StatusTeapot)
+		}
+	})
+}
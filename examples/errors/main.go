@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/nicolito128/plugo"
+)
+
+var users = map[string]string{"1": "Alice", "2": "Bob"}
+
+func main() {
+	router := plugo.New()
+
+	// Handlers registered with the C suffix receive a Connection and return an error
+	router.GetC("/users/:id", user)
+
+	fmt.Println("Server running at http://localhost:8080/ - Press CTRL+C to exit")
+	log.Fatal(http.ListenAndServe(":8080", router))
+}
+
+func user(conn plugo.Connection) error {
+	id, _ := conn.Param("id")
+
+	name, ok := users[id]
+	if !ok {
+		// The error is sent to the client by the router ErrorHandler
+		return plugo.NewHTTPError(http.StatusNotFound, "user not found")
+	}
+
+	return conn.String(http.StatusOK, "Hello, %s", name)
+}
@@ -0,0 +1,64 @@
+package plugo
+
+// Group creates a new Router scoped to the given prefix. Every route registered
+// through the group is inserted in the same node tree as its parent, prefixed
+// with the group prefix, and executes the group middlewares after the ones of
+// its parents and before the ones of the route.
+func (rt *Router) Group(prefix string, middlewares ...MiddlewareFunc) *Router {
+	group := &Router{
+		routes:       rt.routes,
+		namedRoutes:  rt.namedRoutes,
+		routeNames:   rt.routeNames,
+		paramTypes:   rt.paramTypes,
+		methods:      rt.methods,
+		middlewares:  make([]MiddlewareFunc, 0, len(middlewares)),
+		prefix:       joinPaths(rt.prefix, prefix),
+		parent:       rt,
+		matchers:     rt.matchers,
+		RouterConfig: rt.RouterConfig,
+	}
+
+	group.middlewares = append(group.middlewares, middlewares...)
+
+	if rt.version != nil {
+		version := *rt.version
+		version.prefix = joinPaths(rt.version.prefix, prefix)
+		group.version = &version
+	}
+
+	return group
+}
+
+// Route creates a new group for the given prefix and calls fn with it,
+// useful to declare nested routes in a block.
+func (rt *Router) Route(prefix string, fn func(r *Router)) *Router {
+	group := rt.Group(prefix)
+	if fn != nil {
+		fn(group)
+	}
+
+	return group
+}
+
+// root returns the router that owns the node tree.
+func (rt *Router) root() *Router {
+	for rt.parent != nil {
+		rt = rt.parent
+	}
+
+	return rt
+}
+
+// middlewareChain returns the middlewares of rt and all its parents, from the
+// root router to rt, followed by the given ones.
+func (rt *Router) middlewareChain(middlewares ...MiddlewareFunc) []MiddlewareFunc {
+	var chain []MiddlewareFunc
+	if rt.parent != nil {
+		chain = rt.parent.middlewareChain()
+	}
+
+	chain = append(chain, rt.middlewares...)
+	chain = append(chain, middlewares...)
+
+	return chain
+}
@@ -0,0 +1,75 @@
+package plugo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	var calls []string
+
+	trace := func(name string) MiddlewareFunc {
+		return func(fail *error) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+			}
+		}
+	}
+
+	handler := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			conn := NewConnection(w, r)
+			conn.String(http.StatusOK, "%s %s", name, strings.Join(conn.PathParams(), ","))
+		}
+	}
+
+	router := New()
+	router.Use(trace("root"))
+
+	api := router.Group("/api", trace("api"))
+	api.Route("/v1", func(v1 *Router) {
+		v1.Use(trace("v1"))
+		v1.Get("/users", handler("users"), trace("users"))
+
+		users := v1.Group("/users/:id")
+		users.Get("/posts", handler("posts"))
+		users.Get("/files/{[0-9]+}", handler("files"))
+
+		v1.Group("/static/*").Get("", handler("static"))
+	})
+
+	var tests = []struct {
+		name  string
+		path  string
+		code  int
+		body  string
+		calls string
+	}{
+		{"static route", "/api/v1/users", http.StatusOK, "users ", "root,api,v1,users"},
+		{"param prefix", "/api/v1/users/42/posts", http.StatusOK, "posts 42", "root,api,v1"},
+		{"regexp segment", "/api/v1/users/42/files/7", http.StatusOK, "files 42", "root,api,v1"},
+		{"catch all prefix", "/api/v1/static/logo.png", http.StatusOK, "static ", "root,api,v1"},
+		{"missing prefix", "/v1/users", http.StatusNotFound, "", ""},
+	}
+
+	for _, test := range tests {
+		calls = nil
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if w.Code != test.code {
+			t.Errorf("%s got code %d want %d", test.name, w.Code, test.code)
+		}
+
+		if test.code == http.StatusOK && w.Body.String() != test.body {
+			t.Errorf("%s got body '%s' want '%s'", test.name, w.Body.String(), test.body)
+		}
+
+		if got := strings.Join(calls, ","); got != test.calls {
+			t.Errorf("%s got middlewares '%s' want '%s'", test.name, got, test.calls)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package plugo
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// hostKind orders the host patterns by priority.
+type hostKind uint8
+
+const (
+	hostExact hostKind = iota
+	hostParams
+	hostWildcard
+)
+
+// hostRoute is a host pattern with the router that serves its requests.
+type hostRoute struct {
+	// labels of the pattern, without the leading wildcard
+	labels []string
+
+	// names of the parametric labels, in order
+	keys []string
+
+	kind   hostKind
+	router *Router
+}
+
+// Host creates a new Router that only serves the requests whose host matches
+// pattern, like api.example.com, {tenant}.example.com or *.example.com.
+// Labels in braces are parameters read like the ones of the path, and a leading
+// * matches one or more labels. The port of the request host is ignored and the
+// letters are compared without case. Exact hosts take priority over the ones
+// with parameters, and those over wildcards. The requests whose host does not
+// match any pattern are served by the routes of the root router.
+func (rt *Router) Host(pattern string) *Router {
+	host := &hostRoute{kind: hostExact}
+
+	labels := strings.Split(strings.TrimSuffix(pattern, "."), ".")
+	if labels[0] == "*" {
+		host.kind = hostWildcard
+		labels = labels[1:]
+	}
+
+	for i, label := range labels {
+		switch {
+		case label == "" || strings.ContainsAny(label, "*/:"):
+			panic(fmt.Errorf("%w: %s", ErrInvalidHost, pattern))
+
+		case label[0] == '{' && label[len(label)-1] == '}':
+			name := label[1 : len(label)-1]
+			if !isParamName(name) {
+				panic(fmt.Errorf("%w: %s", ErrInvalidHost, pattern))
+			}
+
+			host.keys = append(host.keys, name)
+			if host.kind == hostExact {
+				host.kind = hostParams
+			}
+
+		default:
+			// the names of the parameters keep their case
+			labels[i] = strings.ToLower(label)
+		}
+	}
+
+	host.labels = labels
+	host.router = &Router{
+		routes:       newNode(rt.IndexPath),
+		namedRoutes:  make(map[string]*node),
+		routeNames:   rt.routeNames,
+		paramTypes:   rt.paramTypes,
+		methods:      rt.methods,
+		middlewares:  make([]MiddlewareFunc, 0),
+		prefix:       rt.prefix,
+		parent:       rt,
+		RouterConfig: rt.RouterConfig,
+	}
+
+	// keeps the hosts sorted by priority, in registration order for the same kind
+	root := rt.root()
+	i := len(root.hosts)
+	for i > 0 && root.hosts[i-1].kind > host.kind {
+		i--
+	}
+
+	root.hosts = append(root.hosts, nil)
+	copy(root.hosts[i+1:], root.hosts[i:])
+	root.hosts[i] = host
+
+	return host.router
+}
+
+// matchHost returns the router of the first host pattern matching the host of
+// a request, adding the values of its parameters to rctx, or nil if there is none.
+func (rt *Router) matchHost(host string, rctx *RoutingContext) *Router {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+
+	labels := strings.Split(strings.ToLower(strings.TrimSuffix(host, ".")), ".")
+	for _, h := range rt.hosts {
+		if !h.match(labels) {
+			continue
+		}
+
+		// the labels matched by the pattern, without the ones of the wildcard
+		labels = labels[len(labels)-len(h.labels):]
+
+		k := 0
+		for i, label := range h.labels {
+			if label[0] == '{' {
+				rctx.Params = append(rctx.Params, Param{Key: h.keys[k], Value: labels[i]})
+				rctx.values = append(rctx.values, labels[i])
+				rctx.parsed = append(rctx.parsed, nil)
+				k++
+			}
+		}
+
+		return h.router
+	}
+
+	return nil
+}
+
+// match reports whether the labels of a host match the pattern.
+func (h *hostRoute) match(labels []string) bool {
+	if h.kind == hostWildcard {
+		if len(labels) <= len(h.labels) {
+			return false
+		}
+
+		labels = labels[len(labels)-len(h.labels):]
+	} else if len(labels) != len(h.labels) {
+		return false
+	}
+
+	for i, label := range h.labels {
+		if labels[i] == "" || label[0] != '{' && label != labels[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,99 @@
+package plugo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHost(t *testing.T) {
+	router := New()
+	router.GetC("/", func(conn Connection) error {
+		return conn.String(http.StatusOK, "fallback")
+	})
+
+	api := router.Host("api.example.com")
+	api.GetC("/users/:id", func(conn Connection) error {
+		id, _ := conn.Param("id")
+		return conn.String(http.StatusOK, "api %s", id)
+	})
+
+	tenants := router.Host("{tenant}.example.com")
+	tenants.GetC("/", func(conn Connection) error {
+		tenant, _ := conn.Param("tenant")
+		return conn.String(http.StatusOK, "tenant %s", tenant)
+	})
+	tenants.Group("/admin").GetC("/:page", func(conn Connection) error {
+		tenant, _ := conn.Param("tenant")
+		page, _ := conn.Param("page")
+		return conn.String(http.StatusOK, "tenant %s %s", tenant, page)
+	})
+
+	router.Host("{regionID}.{tenantID}.Example.NET").GetC("/", func(conn Connection) error {
+		region, _ := conn.Param("regionID")
+		tenant, ok := conn.Param("tenantID")
+		return conn.String(http.StatusOK, "tenant %s %s %t", tenant, region, ok)
+	})
+
+	router.Host("*.example.org").GetC("/", func(conn Connection) error {
+		return conn.String(http.StatusOK, "wildcard")
+	})
+
+	var tests = []struct {
+		name string
+		host string
+		path string
+		code int
+		body string
+	}{
+		{"exact", "api.example.com", "/users/1", http.StatusOK, "api 1"},
+		{"port", "api.example.com:8080", "/users/2", http.StatusOK, "api 2"},
+		{"case", "API.Example.COM", "/users/3", http.StatusOK, "api 3"},
+		{"exact over params", "api.example.com", "/", http.StatusNotFound, "404 page not found\n"},
+		{"param", "acme.example.com", "/", http.StatusOK, "tenant acme"},
+		{"param with path params", "acme.example.com:443", "/admin/billing", http.StatusOK, "tenant acme billing"},
+		{"param takes one label", "a.b.example.com", "/", http.StatusOK, "fallback"},
+		{"param names keep their case", "eu.Acme.example.net", "/", http.StatusOK, "tenant acme eu true"},
+		{"wildcard", "a.b.example.org", "/", http.StatusOK, "wildcard"},
+		{"wildcard needs a label", "example.org", "/", http.StatusOK, "fallback"},
+		{"fallback", "localhost:3000", "/", http.StatusOK, "fallback"},
+		{"fallback routes", "localhost", "/users/1", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, test.path, nil)
+		r.Host = test.host
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s got %d '%s' want %d '%s'", test.name, w.Code, w.Body.String(), test.code, test.body)
+		}
+	}
+
+	t.Run("walk", func(t *testing.T) {
+		var hosts []string
+		for _, route := range router.Routes() {
+			if route.Host != "" {
+				hosts = append(hosts, route.Host)
+			}
+		}
+
+		if !containsString(hosts, "{regionID}.{tenantID}.example.net") {
+			t.Errorf("got hosts %v", hosts)
+		}
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, ErrInvalidHost) {
+				t.Errorf("got panic %v want %v", err, ErrInvalidHost)
+			}
+		}()
+
+		router.Host("api.*.example.com")
+	})
+}
@@ -1,42 +1,107 @@
-package plugo
-
-import (
-	"net/http"
-)
-
-// HTTP Method Wrapper
-type MethodID string
-
-func (mid MethodID) Match(exp string) bool {
-	return string(mid) == exp
-}
-
-func (mid MethodID) Allowed() bool {
-	switch mid {
-	case
-		MethodGet,
-		MethodPost,
-		MethodPut,
-		MethodDelete,
-		MethodConnect,
-		MethodHead,
-		MethodOptions,
-		MethodTrace:
-
-		return true
-	}
-
-	return false
-}
-
-// net/http method wrapped
-const (
-	MethodGet     MethodID = http.MethodGet
-	MethodPost             = http.MethodPost
-	MethodPut              = http.MethodPut
-	MethodDelete           = http.MethodDelete
-	MethodConnect          = http.MethodConnect
-	MethodHead             = http.MethodHead
-	MethodOptions          = http.MethodOptions
-	MethodTrace            = http.MethodTrace
-)
+package plugo
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// HTTP Method Wrapper
+type MethodID string
+
+func (mid MethodID) Match(exp string) bool {
+	return string(mid) == exp
+}
+
+func (mid MethodID) Allowed() bool {
+	switch mid {
+	case
+		MethodGet,
+		MethodPost,
+		MethodPut,
+		MethodPatch,
+		MethodDelete,
+		MethodConnect,
+		MethodHead,
+		MethodOptions,
+		MethodTrace:
+
+		return true
+	}
+
+	return false
+}
+
+// RegisterMethod allows the routes of the router and its groups to use an
+// extension method, like the WebDAV PROPFIND, which must be a valid token
+// as defined by RFC 7230.
+func (rt *Router) RegisterMethod(name string) {
+	if !isMethodToken(name) {
+		panic(fmt.Errorf("%w: %q", ErrInvalidMethod, name))
+	}
+
+	rt.methods[MethodID(name)] = true
+}
+
+// allowsMethod reports whether routes can be registered in method.
+func (rt *Router) allowsMethod(method MethodID) bool {
+	return method.Allowed() || rt.methods[method]
+}
+
+// anyMethods returns the standard methods followed by the extension methods
+// registered in the router, sorted by name.
+func (rt *Router) anyMethods() []MethodID {
+	methods := []MethodID{MethodGet, MethodHead, MethodPost, MethodPut, MethodPatch, MethodDelete, MethodConnect, MethodOptions, MethodTrace}
+
+	var extensions []string
+	for method := range rt.methods {
+		if !method.Allowed() {
+			extensions = append(extensions, string(method))
+		}
+	}
+	sort.Strings(extensions)
+
+	for _, method := range extensions {
+		methods = append(methods, MethodID(method))
+	}
+
+	return methods
+}
+
+// isMethodToken reports whether s is a token as defined by RFC 7230:
+//
+//	token = 1*tchar
+//	tchar = "!" / "#" / "$" / "%" / "&" / "'" / "*" / "+" / "-" / "." /
+//	        "^" / "_" / "`" / "|" / "~" / DIGIT / ALPHA
+func isMethodToken(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			continue
+		}
+
+		if strings.IndexByte("!#$%&'*+-.^_`|~", c) < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// net/http method wrapped
+const (
+	MethodGet     MethodID = http.MethodGet
+	MethodPost             = http.MethodPost
+	MethodPut              = http.MethodPut
+	MethodPatch            = http.MethodPatch
+	MethodDelete           = http.MethodDelete
+	MethodConnect          = http.MethodConnect
+	MethodHead             = http.MethodHead
+	MethodOptions          = http.MethodOptions
+	MethodTrace            = http.MethodTrace
+)
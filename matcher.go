@@ -0,0 +1,64 @@
+package plugo
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Matcher reports whether a route accepts a request, to choose between the
+// routes registered for the same path and method.
+type Matcher func(r *http.Request) bool
+
+// When creates a new Router whose routes only serve the requests accepted by
+// every matcher, besides the ones of its parents. The routes with matchers for
+// the same path and method are tried in registration order, before the route
+// without matchers. The requests not accepted by any of them are not found.
+//
+//	router.Get("/items", listItems)
+//	router.When(plugo.MatchHeader("X-Api-Version", "2")).Get("/items", listItemsV2)
+func (rt *Router) When(matchers ...Matcher) *Router {
+	group := rt.Group("")
+	group.matchers = append(append(make([]Matcher, 0, len(rt.matchers)+len(matchers)), rt.matchers...), matchers...)
+
+	return group
+}
+
+// MatchHeader accepts the requests with the header key set to value, or with
+// any value if value is empty.
+func MatchHeader(key, value string) Matcher {
+	return func(r *http.Request) bool {
+		values := r.Header.Values(key)
+		if value == "" {
+			return len(values) > 0
+		}
+
+		return containsString(values, value)
+	}
+}
+
+// MatchQuery accepts the requests with the query parameter key, with any value.
+func MatchQuery(key string) Matcher {
+	return func(r *http.Request) bool {
+		return r.URL.Query().Has(key)
+	}
+}
+
+// MatchContentType accepts the requests whose media type is one of types,
+// like application/json, ignoring its parameters and case.
+func MatchContentType(types ...string) Matcher {
+	return func(r *http.Request) bool {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			return false
+		}
+
+		for _, typ := range types {
+			if strings.EqualFold(mediaType, typ) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
@@ -0,0 +1,102 @@
+package plugo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWhen(t *testing.T) {
+	router := New(func(config *RouterConfig) {
+		config.StrictRoutes = true
+	})
+
+	reply := func(body string) HandlerFunc {
+		return func(conn Connection) error {
+			return conn.String(http.StatusOK, body)
+		}
+	}
+
+	router.GetC("/items", reply("items"))
+	router.When(MatchHeader("X-Api-Version", "2")).GetC("/items", reply("items v2"))
+	router.When(MatchQuery("debug")).GetC("/items", reply("items debug"))
+
+	router.When(MatchContentType("application/json")).PostC("/items/:id", reply("json"))
+	router.When(func(r *http.Request) bool { return r.ContentLength == 0 }).PostC("/items/:id", reply("empty"))
+	router.PostC("/items/*rest", reply("catch all"))
+
+	v3 := router.When(MatchHeader("X-Api-Version", "3")).Group("/v3")
+	v3.GetC("/items", reply("v3 items"))
+
+	var tests = []struct {
+		name   string
+		method string
+		path   string
+		header http.Header
+		body   string
+		code   int
+		want   string
+	}{
+		{"no matchers", http.MethodGet, "/items", nil, "", http.StatusOK, "items"},
+		{"header", http.MethodGet, "/items", http.Header{"X-Api-Version": {"2"}}, "", http.StatusOK, "items v2"},
+		{"other header value", http.MethodGet, "/items", http.Header{"X-Api-Version": {"1"}}, "", http.StatusOK, "items"},
+		{"registration order", http.MethodGet, "/items?debug", http.Header{"X-Api-Version": {"2"}}, "", http.StatusOK, "items v2"},
+		{"query", http.MethodGet, "/items?debug=1", nil, "", http.StatusOK, "items debug"},
+		{"head", http.MethodHead, "/items?debug", nil, "", http.StatusOK, ""},
+		{"content type", http.MethodPost, "/items/1", http.Header{"Content-Type": {"Application/JSON; charset=utf-8"}}, "{}", http.StatusOK, "json"},
+		{"custom", http.MethodPost, "/items/1", nil, "", http.StatusOK, "empty"},
+		{"next route", http.MethodPost, "/items/1", http.Header{"Content-Type": {"text/plain"}}, "a", http.StatusOK, "catch all"},
+		{"group", http.MethodGet, "/v3/items", http.Header{"X-Api-Version": {"3"}}, "", http.StatusOK, "v3 items"},
+		{"group not accepted", http.MethodGet, "/v3/items", nil, "", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+		for key, values := range test.header {
+			r.Header[key] = values
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code || w.Body.String() != test.want {
+			t.Errorf("%s got %d '%s' want %d '%s'", test.name, w.Code, w.Body.String(), test.code, test.want)
+		}
+	}
+
+	t.Run("not allowed methods skip the request method", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items/1", nil))
+
+		if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "OPTIONS, POST" {
+			t.Errorf("got %d '%s' want %d 'OPTIONS, POST'", w.Code, w.Header().Get("Allow"), http.StatusMethodNotAllowed)
+		}
+	})
+
+	t.Run("middlewares of the route", func(t *testing.T) {
+		deny := func(fail *error) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				*fail = errors.New("forbidden")
+				http.Error(w, "forbidden", http.StatusForbidden)
+			}
+		}
+
+		router := New()
+		router.When(MatchHeader("X-Role", "guest")).GetC("/reports", reply("guest reports"), deny)
+		router.When(MatchHeader("X-Role", "admin")).GetC("/reports", reply("admin reports"))
+
+		for role, want := range map[string]int{"guest": http.StatusForbidden, "admin": http.StatusOK} {
+			r := httptest.NewRequest(http.MethodGet, "/reports", nil)
+			r.Header.Set("X-Role", role)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != want {
+				t.Errorf("%s got %d want %d", role, w.Code, want)
+			}
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package plugo
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var mountContextKey = &contextKey{"mount"}
+
+// mountInfo keeps the data of a request forwarded to a mounted handler.
+type mountInfo struct {
+	// accumulated prefix stripped from the request path
+	path string
+
+	// url of the request before stripping any prefix
+	original *url.URL
+}
+
+// Mount forwards every method and every sub-path under pattern to handler,
+// which can be any http.Handler, including another Router. The matched prefix
+// is stripped from the request path; use MountPath and OriginalURL to recover it.
+// Routes registered in the router take priority over the mounted handler.
+func (rt *Router) Mount(pattern string, handler http.Handler) {
+	if handler == nil {
+		panic(ErrMountNilHandler)
+	}
+
+	pattern = joinPaths(rt.IndexPath, joinPaths(rt.prefix, pattern))
+
+	// the prefix matches every sub-path, with or without a trailing slash
+	moves := rt.parsePatternToMovements(cleanPath(pattern))
+	if len(moves) > 0 && moves[len(moves)-1] == "/" {
+		moves = moves[:len(moves)-1]
+	}
+
+	if err := rt.checkPattern("", cleanPath(pattern)); err != nil {
+		panic(err)
+	}
+
+	root := rt.insertMovements(moves)
+	root.mount = &endpoint{
+		handler:      handler,
+		pattern:      pattern,
+		routePattern: pattern,
+		paramKeys:    parseParamKeysFromPattern(cleanPath(pattern)),
+		router:       rt,
+	}
+}
+
+// MountPath returns the prefix stripped from the request path by the mounts
+// the request went through, or an empty string if it was not forwarded by Mount.
+func MountPath(r *http.Request) string {
+	info, ok := r.Context().Value(mountContextKey).(*mountInfo)
+	if !ok {
+		return ""
+	}
+
+	return info.path
+}
+
+// OriginalURL returns the URL of the request before any mount stripped its prefix.
+func OriginalURL(r *http.Request) *url.URL {
+	info, ok := r.Context().Value(mountContextKey).(*mountInfo)
+	if !ok {
+		return r.URL
+	}
+
+	return info.original
+}
+
+// stripSegments returns a handler that removes the first n segments of the
+// request path before calling h, counting them in the escaped path if escaped is true.
+func stripSegments(h http.Handler, n int, escaped bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := cleanPath(r.URL.Path)
+		if escaped {
+			path = cleanPath(r.URL.EscapedPath())
+		}
+
+		// index where the remaining path starts
+		end := 0
+		for i := 0; i < n; i++ {
+			next := strings.IndexByte(path[end+1:], '/')
+			if next < 0 {
+				end = len(path)
+				break
+			}
+
+			end += next + 1
+		}
+
+		prefix, rest := path[:end], path[end:]
+		if rest == "" {
+			rest = "/"
+		}
+
+		// MountPath is unescaped like the path of the request
+		mounted := prefix
+		if escaped {
+			mounted, _ = url.PathUnescape(prefix)
+		}
+
+		info := &mountInfo{path: mounted, original: r.URL}
+		if parent, ok := r.Context().Value(mountContextKey).(*mountInfo); ok {
+			info.path = parent.path + mounted
+			info.original = parent.original
+		}
+
+		u := new(url.URL)
+		*u = *r.URL
+		u.Path = rest
+		u.RawPath = ""
+		if escaped {
+			// the escaped path is always well formed
+			u.Path, _ = url.PathUnescape(rest)
+			u.RawPath = rest
+		} else if r.URL.RawPath != "" {
+			if raw := strings.TrimPrefix(r.URL.RawPath, prefix); raw != r.URL.RawPath {
+				u.RawPath = raw
+			}
+		}
+
+		r2 := r.WithContext(context.WithValue(r.Context(), mountContextKey, info))
+		r2.URL = u
+
+		h.ServeHTTP(w, r2)
+	})
+}
@@ -0,0 +1,59 @@
+package plugo
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %s %s", r.Method, r.URL.Path, MountPath(r), OriginalURL(r).Path)
+	})
+
+	admin := New()
+	admin.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "admin home %s", MountPath(r))
+	})
+	admin.Mount("/raw", echo)
+
+	router := New()
+	router.Get("/files/readme", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("readme"))
+	})
+	router.Mount("/files", echo)
+	router.Mount("/admin", admin)
+	router.Group("/users/:id").Mount("/assets", echo)
+
+	var tests = []struct {
+		name   string
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{"mount root", http.MethodGet, "/files", http.StatusOK, "GET / /files /files"},
+		{"sub path", http.MethodPost, "/files/a/b.txt", http.StatusOK, "POST /a/b.txt /files /files/a/b.txt"},
+		{"any method", http.MethodDelete, "/files/readme", http.StatusOK, "DELETE /readme /files /files/readme"},
+		{"route priority", http.MethodGet, "/files/readme", http.StatusOK, "readme"},
+		{"sub router", http.MethodGet, "/admin/", http.StatusOK, "admin home /admin"},
+		{"nested mount", http.MethodPut, "/admin/raw/x", http.StatusOK, "PUT /x /admin/raw /admin/raw/x"},
+		{"sub router not found", http.MethodGet, "/admin/missing", http.StatusNotFound, "404 page not found\n"},
+		{"param prefix", http.MethodGet, "/users/7/assets/logo.png", http.StatusOK, "GET /logo.png /users/7/assets /users/7/assets/logo.png"},
+		{"outside prefix", http.MethodGet, "/filesystem", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+		if w.Code != test.code {
+			t.Errorf("%s got code %d want %d", test.name, w.Code, test.code)
+		}
+
+		if w.Body.String() != test.body {
+			t.Errorf("%s got body '%s' want '%s'", test.name, w.Body.String(), test.body)
+		}
+	}
+}
@@ -1,187 +1,672 @@
-package plugo
-
-import (
-	"net/http"
-	"regexp"
-)
-
-// MidlewareFunc represents a function that is executed before or after an http request.
-type MiddlewareFunc func(*error) http.HandlerFunc
-
-// node represents a single route node of the tree.
-type node struct {
-	// type of the node
-	kind nodeType
-
-	// pattern expression of the current node
-	label string
-
-	// regexp matcher for regexp nodes
-	rex *regexp.Regexp
-
-	// http handler endpoints
-	endpoints endpoints
-
-	// slice of middlewares to execute after a request
-	middlewares []MiddlewareFunc
-
-	// parent node
-	parent *node
-
-	// catch all nodes
-	catchAll *node
-
-	// parametric nodes
-	params *node
-
-	// slice with static and regexp nodes
-	children []*node
-
-	// slice of regexp node
-	matchers []*node
-
-	// slice of static node
-	statics []*node
-
-	// isLeaf indicates that node does not have child routes
-	isLeaf bool
-
-	// isHandler indicate if the node has any http handler func registered
-	isHandler bool
-}
-
-type nodeType uint8
-
-const (
-	nodeStatic   nodeType = iota // Ex. /home
-	nodeRegexp                   // Ex. /{[0-9]+}
-	nodeParam                    // Ex. /:user
-	nodeCatchAll                 // Ex. /api/*
-)
-
-func newNode(pattern string) *node {
-	if len(pattern) == 0 {
-		panic(ErrCreateEmptyNode)
-	}
-
-	var exp *regexp.Regexp
-	var err error
-
-	typ := parseStringToNodeType(pattern)
-	if typ == nodeRegexp {
-		if len(pattern) >= 2 {
-			exp, err = regexp.Compile(pattern[1 : len(pattern)-1])
-			if err != nil {
-				panic(ErrPatternNotCompile)
-			}
-		}
-	}
-
-	return &node{
-		kind:      typ,
-		label:     pattern,
-		rex:       exp,
-		endpoints: make(endpoints),
-		catchAll:  nil,
-		params:    nil,
-		children:  make([]*node, 0),
-		matchers:  make([]*node, 0),
-		statics:   make([]*node, 0),
-	}
-}
-
-func (nd *node) bind(mid MethodID, pattern string, handler http.Handler) {
-	nd.endpoints[mid] = &endpoint{
-		handler,
-		pattern,
-	}
-
-	nd.isHandler = true
-}
-
-func (nd *node) use(middlewares ...MiddlewareFunc) {
-	nd.middlewares = append(nd.middlewares, middlewares...)
-}
-
-func (nd *node) insertNode(label string) *node {
-	newElement := newNode(label)
-	newElement.isLeaf = true
-	newElement.parent = nd
-	nd.isLeaf = false
-
-	switch newElement.kind {
-	case nodeRegexp:
-		nd.matchers = append(nd.matchers, newElement)
-		nd.children = append(nd.children, newElement)
-
-	case nodeStatic:
-		nd.statics = append(nd.statics, newElement)
-		nd.children = append(nd.children, newElement)
-
-	case nodeParam:
-		// clear for generic parameter
-		newElement.label = ":"
-
-		// saves the data of the previous node before overwriting it
-		if nd.params != nil {
-			newElement.children = append(newElement.children, nd.children...)
-			newElement.matchers = append(newElement.matchers, nd.matchers...)
-			newElement.statics = append(newElement.statics, nd.statics...)
-			newElement.catchAll = nd.catchAll
-			newElement.endpoints = nd.endpoints
-		}
-
-		nd.params = newElement
-
-	case nodeCatchAll:
-		nd.catchAll = newElement
-	}
-
-	return newElement
-}
-
-// match checks for matches on static and regexp nodes.
-func (nd *node) match(exp string) bool {
-	switch nd.kind {
-	case nodeStatic:
-		if nd.label == exp {
-			return true
-		}
-
-	case nodeRegexp:
-		if nd.rex != nil {
-			ok := nd.rex.MatchString(exp)
-			if ok {
-				return true
-			}
-		}
-	}
-
-	return false
-}
-
-func (nd *node) findRoute(search string) *node {
-	ok := nd.match(search)
-	if ok {
-		return nd
-	}
-
-	if len(nd.children) > 0 {
-		for _, child := range nd.children {
-			ok = child.match(search)
-			if ok {
-				return child
-			}
-		}
-	}
-
-	if nd.params != nil {
-		return nd.params
-	}
-
-	if nd.catchAll != nil {
-		return nd.catchAll
-	}
-
-	return nil
-}
+package plugo
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// MidlewareFunc represents a function that is executed before or after an http request.
+type MiddlewareFunc func(*error) http.HandlerFunc
+
+// node represents a single route node of the tree.
+type node struct {
+	// type of the node
+	kind nodeType
+
+	// pattern expression of the current node, static nodes keep a chain of
+	// segments without branches in a single label, like repos/issues
+	label string
+
+	// number of path segments of the label
+	segments int
+
+	// regexp matcher for regexp nodes
+	rex *regexp.Regexp
+
+	// http handler endpoints
+	endpoints endpoints
+
+	// handler mounted to serve every method and sub-path of the node
+	mount *endpoint
+
+	// parent node
+	parent *node
+
+	// catch all nodes
+	catchAll *node
+
+	// parametric nodes
+	params *node
+
+	// parametric nodes with a type constraint, like :id<int>
+	typedParams []*node
+
+	// parser of the type constraint of a parametric node
+	check ParamType
+
+	// slice with static and regexp nodes
+	children []*node
+
+	// slice of regexp node
+	matchers []*node
+
+	// slice of composite nodes, sorted by the length of their static text
+	composites []*node
+
+	// parsers of the type constraints of the parameters of a composite node
+	checks []ParamType
+
+	// names of the values collected by the segment that created the node
+	keys []string
+
+	// slice of static node
+	statics []*node
+
+	// first byte of the label of every static node, in the same order
+	indices []byte
+
+	// isLeaf indicates that node does not have child routes
+	isLeaf bool
+
+	// isHandler indicate if the node has any http handler func registered
+	isHandler bool
+}
+
+type nodeType uint8
+
+const (
+	nodeStatic    nodeType = iota // Ex. /home
+	nodeRegexp                    // Ex. /{[0-9]+}
+	nodeParam                     // Ex. /:user
+	nodeCatchAll                  // Ex. /api/* or /files/*filepath
+	nodeComposite                 // Ex. /:name.:ext or /v:major
+)
+
+func newNode(pattern string) *node {
+	if len(pattern) == 0 {
+		panic(ErrCreateEmptyNode)
+	}
+
+	var exp *regexp.Regexp
+	var err error
+
+	typ := parseStringToNodeType(pattern)
+	if typ == nodeRegexp {
+		exp, err = compileSegmentRegexp(pattern)
+		if err != nil {
+			panic(ErrPatternNotCompile)
+		}
+
+		// the name is kept by the endpoints, so segments with the same expression share the node
+		pattern = regexpNodeLabel(pattern)
+	}
+
+	if typ == nodeComposite {
+		parts := parseCompositeSegment(pattern)
+
+		exp, err = compileCompositeSegment(parts)
+		if err != nil {
+			panic(ErrPatternNotCompile)
+		}
+
+		pattern = compositeNodeLabel(parts)
+	}
+
+	if typ == nodeStatic {
+		pattern = unescapeStatic(pattern)
+	}
+
+	return &node{
+		kind:      typ,
+		label:     pattern,
+		segments:  1,
+		rex:       exp,
+		endpoints: make(endpoints),
+		catchAll:  nil,
+		params:    nil,
+		children:  make([]*node, 0),
+		matchers:  make([]*node, 0),
+		statics:   make([]*node, 0),
+	}
+}
+
+func (nd *node) bind(rt *Router, mid MethodID, pattern string, handler http.Handler, matchers ...Matcher) *endpoint {
+	endp := &endpoint{
+		handler:      handler,
+		method:       mid,
+		pattern:      pattern,
+		routePattern: pattern,
+		router:       rt,
+		matchers:     matchers,
+	}
+
+	nd.endpoints.add(endp)
+	nd.isHandler = true
+
+	return endp
+}
+
+func (nd *node) insertNode(label string) *node {
+	newElement := newNode(label)
+	newElement.isLeaf = true
+	newElement.parent = nd
+	nd.isLeaf = false
+
+	switch newElement.kind {
+	case nodeRegexp:
+		nd.matchers = append(nd.matchers, newElement)
+		nd.children = append(nd.children, newElement)
+
+	case nodeStatic:
+		nd.statics = append(nd.statics, newElement)
+		nd.indices = append(nd.indices, newElement.label[0])
+		nd.children = append(nd.children, newElement)
+
+	case nodeComposite:
+		// segments with more static text are more specific and go first
+		i := len(nd.composites)
+		for i > 0 && compositeStaticLen(nd.composites[i-1].label) < compositeStaticLen(newElement.label) {
+			i--
+		}
+
+		nd.composites = append(nd.composites, nil)
+		copy(nd.composites[i+1:], nd.composites[i:])
+		nd.composites[i] = newElement
+		nd.children = append(nd.children, newElement)
+
+	case nodeParam:
+		if _, typ := splitParamSegment(label); typ != "" {
+			// the name is kept by the endpoints, so params with the same type share the node
+			newElement.label = ":<" + typ + ">"
+			nd.typedParams = append(nd.typedParams, newElement)
+			break
+		}
+
+		// the name is kept by the endpoints, so params share the node
+		newElement.label = ":"
+		nd.params = newElement
+
+	case nodeCatchAll:
+		// the name is kept by the endpoints
+		newElement.label = "*"
+		nd.catchAll = newElement
+	}
+
+	return newElement
+}
+
+// split moves the first n segments of the label of a static node to a new
+// node that takes its place in the tree, so other routes can end or branch
+// after them. The node keeps its endpoints and children.
+func (nd *node) split(n int) *node {
+	i := 0
+	for k := 0; k < n; k++ {
+		i += strings.IndexByte(nd.label[i:], '/') + 1
+	}
+
+	// the label is not parsed again, as its colons are already unescaped
+	prefix := newNode("/")
+	prefix.label = nd.label[:i-1]
+	prefix.segments = n
+	prefix.parent = nd.parent
+	prefix.statics = append(prefix.statics, nd)
+	prefix.indices = append(prefix.indices, nd.label[i])
+	prefix.children = append(prefix.children, nd)
+
+	for k, child := range nd.parent.statics {
+		if child == nd {
+			nd.parent.statics[k] = prefix
+		}
+	}
+
+	for k, child := range nd.parent.children {
+		if child == nd {
+			nd.parent.children[k] = prefix
+		}
+	}
+
+	nd.label = nd.label[i:]
+	nd.segments -= n
+	nd.parent = prefix
+
+	return prefix
+}
+
+// staticChild returns the static child whose label starts with the first of
+// moves and how many of the moves are segments of its label.
+func (nd *node) staticChild(moves []string) (*node, int) {
+	for i, c := range nd.indices {
+		if c != moves[0][0] {
+			continue
+		}
+
+		if n := nd.statics[i].matchStatic(moves, false); n > 0 {
+			return nd.statics[i], n
+		}
+	}
+
+	return nil, 0
+}
+
+// matchStatic returns how many of the first moves are segments of the label
+// of a static node, ignoring the case of ASCII letters if fold is true.
+func (nd *node) matchStatic(moves []string, fold bool) int {
+	label := nd.label
+	for n, move := range moves {
+		if len(label) < len(move) {
+			return n
+		}
+
+		if fold && !equalFoldASCII(label[:len(move)], move) || !fold && label[:len(move)] != move {
+			return n
+		}
+
+		label = label[len(move):]
+		if label == "" {
+			return n + 1
+		}
+
+		if label[0] != '/' {
+			return n
+		}
+
+		label = label[1:]
+	}
+
+	return len(moves)
+}
+
+// lookup returns the child created for the first segments of a route pattern
+// and how many of them it takes, or nil if there is none.
+func (nd *node) lookup(moves []string) (*node, int) {
+	if n := staticSegments(moves); n > 0 {
+		child, k := nd.staticChild(unescapeStatics(moves[:n]))
+		if child == nil || k < child.segments {
+			return nil, 0
+		}
+
+		return child, k
+	}
+
+	return nd.findNode(moves[0]), 1
+}
+
+// staticSegments returns the number of static segments at the start of moves
+// that can be chained in the label of a single node.
+func staticSegments(moves []string) int {
+	for n, move := range moves {
+		if move == "/" || parseStringToNodeType(move) != nodeStatic {
+			return n
+		}
+	}
+
+	return len(moves)
+}
+
+// matchComposite returns the values of the parameters of a composite node
+// found in exp, or nil if it does not match.
+func (nd *node) matchComposite(exp string) []string {
+	submatches := nd.rex.FindStringSubmatch(exp)
+	if submatches == nil {
+		return nil
+	}
+
+	values := submatches[1:]
+	for i, check := range nd.checks {
+		if check == nil {
+			continue
+		}
+
+		if _, ok := check(values[i]); !ok {
+			return nil
+		}
+	}
+
+	return values
+}
+
+// routeSearch keeps the state of the search of a request path in the tree.
+type routeSearch struct {
+	// method of the request
+	method MethodID
+
+	// cleaned request path, its segments and the segments as they are in
+	// path, which are escaped if the router uses the escaped path
+	path  string
+	moves []string
+	raw   []string
+
+	// moves are the unescaped segments of an escaped path
+	unescaped bool
+
+	// collects the values of the parametric segments
+	rctx *RoutingContext
+
+	// endpoint found, and the number of segments consumed by its node if it is a mount
+	endpoint *endpoint
+	depth    int
+
+	// serves HEAD requests with GET endpoints
+	autoHead bool
+
+	// request evaluated by the matchers of the endpoints
+	request *http.Request
+
+	// a node for the request path has versioned endpoints for the method, but
+	// none of them accepted the request
+	notAcceptable bool
+
+	// matches static segments ignoring the case of ASCII letters
+	foldCase bool
+}
+
+// next returns the offset in path of the segment that follows n moves from the i-th one at offset.
+func (s *routeSearch) next(offset, i, n int) int {
+	for _, move := range s.raw[i : i+n] {
+		offset += len(move) + 1
+	}
+
+	return offset
+}
+
+// rest returns the unescaped rest of the path from offset for a catch all segment.
+func (s *routeSearch) rest(offset int) string {
+	rest := s.path[offset:]
+	if !s.unescaped {
+		return rest
+	}
+
+	if value, err := url.PathUnescape(rest); err == nil {
+		return value
+	}
+
+	return rest
+}
+
+// endpointOf returns the endpoint of a node for the request method, falling
+// back to the GET endpoint for HEAD requests if autoHead is true.
+func (s *routeSearch) endpointOf(nd *node) *endpoint {
+	method := s.method
+	endp := nd.endpoints.match(method, s.request)
+	if endp == nil && s.autoHead && s.method == MethodHead {
+		method = MethodGet
+		endp = nd.endpoints.match(method, s.request)
+	}
+
+	if endp == nil && nd.endpoints.versioned(method) {
+		s.notAcceptable = true
+	}
+
+	return endp
+}
+
+// allow adds the methods of the endpoints of a node that serves the request
+// path to the AllowedMethods of the routing context. The request method is
+// skipped, since its endpoints did not accept the request.
+func (s *routeSearch) allow(nd *node) {
+	for method := range nd.endpoints {
+		if method == s.method || s.autoHead && s.method == MethodHead && method == MethodGet {
+			continue
+		}
+
+		if !containsString(s.rctx.AllowedMethods, string(method)) {
+			s.rctx.AllowedMethods = append(s.rctx.AllowedMethods, string(method))
+		}
+
+		if method == MethodGet && s.autoHead && !containsString(s.rctx.AllowedMethods, http.MethodHead) {
+			s.rctx.AllowedMethods = append(s.rctx.AllowedMethods, http.MethodHead)
+		}
+	}
+}
+
+// findRoute searches the node that serves the segments of s.moves from the i-th,
+// starting at offset in s.path, and collects the values of its parametric segments.
+//
+// The children of a node are tried in priority order: static, composite,
+// regexp, typed parametric, parametric and catch all. If the rest of the path
+// can not be served from a child with the request method, the search
+// backtracks to the next child, so a more specific segment never hides a
+// route that would match. A mount serves the path only when no child of its
+// node does.
+func (nd *node) findRoute(s *routeSearch, i, offset int) *node {
+	if i == len(s.moves) {
+		if s.endpoint = s.endpointOf(nd); s.endpoint != nil {
+			return nd
+		}
+
+		if nd.mount != nil {
+			s.endpoint, s.depth = nd.mount, i
+			return nd
+		}
+
+		if nd.catchAll != nil {
+			if s.endpoint = s.endpointOf(nd.catchAll); s.endpoint != nil {
+				s.rctx.push("", nil)
+				return nd.catchAll
+			}
+
+			s.allow(nd.catchAll)
+		}
+
+		s.allow(nd)
+		return nil
+	}
+
+	move := s.moves[i]
+	next := s.next(offset, i, 1)
+
+	// the trailing slash kept by SlashStrictly only matches a static "/" node,
+	// and it is told apart from an encoded slash by the escaped segment
+	trailing := s.raw[i] == "/"
+
+	// the first byte of the segment selects the static children to compare
+	for j, c := range nd.indices {
+		if c != move[0] && (!s.foldCase || lowerASCII(c) != lowerASCII(move[0])) {
+			continue
+		}
+
+		child := nd.statics[j]
+		if child.label == "/" && !trailing {
+			continue
+		}
+
+		if child.matchStatic(s.moves[i:], s.foldCase) == child.segments {
+			if found := child.findRoute(s, i+child.segments, s.next(offset, i, child.segments)); found != nil {
+				return found
+			}
+		}
+	}
+
+	if !trailing {
+		if found := nd.findParamRoute(s, i, next); found != nil {
+			return found
+		}
+	}
+
+	// catch all nodes take the rest of the path, slashes included
+	if nd.catchAll != nil {
+		if s.endpoint = s.endpointOf(nd.catchAll); s.endpoint != nil {
+			s.rctx.push(s.rest(offset), nil)
+			return nd.catchAll
+		}
+
+		s.allow(nd.catchAll)
+	}
+
+	if nd.mount != nil {
+		s.endpoint, s.depth = nd.mount, i
+		return nd
+	}
+
+	return nil
+}
+
+// findParamRoute searches the route of the i-th segment of s.moves through the
+// composite, regexp and parametric children of the node, in priority order.
+// next is the offset in s.path of the following segment.
+func (nd *node) findParamRoute(s *routeSearch, i, next int) *node {
+	move := s.moves[i]
+	matched := len(s.rctx.matches)
+
+	for _, child := range nd.composites {
+		values := child.matchComposite(move)
+		if values == nil {
+			continue
+		}
+
+		for j, value := range values {
+			var parsed any
+			if check := child.checks[j]; check != nil {
+				parsed, _ = check(value)
+			}
+
+			s.rctx.push(value, parsed)
+		}
+
+		if found := child.findRoute(s, i+1, next); found != nil {
+			return found
+		}
+
+		s.rctx.pop(matched)
+	}
+
+	for _, child := range nd.matchers {
+		if child.rex.MatchString(move) {
+			s.rctx.push(move, nil)
+			if found := child.findRoute(s, i+1, next); found != nil {
+				return found
+			}
+
+			s.rctx.pop(matched)
+		}
+	}
+
+	for _, child := range nd.typedParams {
+		if parsed, ok := child.check(move); ok {
+			s.rctx.push(move, parsed)
+			if found := child.findRoute(s, i+1, next); found != nil {
+				return found
+			}
+
+			s.rctx.pop(matched)
+		}
+	}
+
+	if nd.params != nil {
+		s.rctx.push(move, nil)
+		if found := nd.params.findRoute(s, i+1, next); found != nil {
+			return found
+		}
+
+		s.rctx.pop(matched)
+	}
+
+	return nil
+}
+
+// canonicalPath rebuilds the path of a request served by nd with the labels
+// of the static nodes from the root, keeping the request values of the
+// dynamic segments and the rest of the path taken by a catch all or a mount.
+// moves are the segments as they are in path, and the labels are escaped if
+// escaped is true.
+func canonicalPath(nd *node, path string, moves []string, escaped bool) string {
+	var chain []*node
+	for ; nd != nil && nd.parent != nil; nd = nd.parent {
+		chain = append(chain, nd)
+	}
+
+	var canonical strings.Builder
+
+	// index of the current move and of its segment in path
+	i, offset := 0, 1
+	for k := len(chain) - 1; k >= 0; k-- {
+		child := chain[k]
+
+		switch {
+		case child.kind == nodeCatchAll:
+			return canonical.String() + path[offset-1:]
+
+		case child.kind == nodeStatic && child.label == "/":
+			canonical.WriteByte('/')
+
+		case child.kind == nodeStatic && escaped:
+			// the request segments are kept if only their escaping differs
+			for j, segment := range strings.Split(child.label, "/") {
+				if value, err := url.PathUnescape(moves[i+j]); err == nil && value == segment {
+					segment = moves[i+j]
+				} else {
+					segment = url.PathEscape(segment)
+				}
+
+				canonical.WriteString("/" + segment)
+			}
+
+		case child.kind == nodeStatic:
+			canonical.WriteString("/" + child.label)
+
+		default:
+			canonical.WriteString("/" + moves[i])
+		}
+
+		for n := 0; n < child.segments; n++ {
+			offset += len(moves[i]) + 1
+			i++
+		}
+	}
+
+	// trailing slash or rest of the path served by a mount
+	if offset-1 < len(path) {
+		canonical.WriteString(path[offset-1:])
+	}
+
+	if canonical.Len() == 0 {
+		return "/"
+	}
+
+	return canonical.String()
+}
+
+// regexpNodeLabel returns the label of the node of a regexp segment, without its name.
+func regexpNodeLabel(segment string) string {
+	_, expr := splitRegexpSegment(segment)
+	return "{" + expr + "}"
+}
+
+// findNode returns the child created for a segment of a route pattern that
+// can not be chained with others, see lookup.
+func (nd *node) findNode(label string) *node {
+	switch parseStringToNodeType(label) {
+	case nodeParam:
+		_, typ := splitParamSegment(label)
+		if typ == "" {
+			return nd.params
+		}
+
+		for _, child := range nd.typedParams {
+			if child.label == ":<"+typ+">" {
+				return child
+			}
+		}
+
+		return nil
+
+	case nodeCatchAll:
+		return nd.catchAll
+
+	case nodeRegexp:
+		label = regexpNodeLabel(label)
+
+	case nodeComposite:
+		label = compositeNodeLabel(parseCompositeSegment(label))
+	}
+
+	for _, child := range nd.children {
+		if child.label == label {
+			return child
+		}
+	}
+
+	return nil
+}
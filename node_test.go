@@ -1,50 +1,103 @@
-package plugo
-
-import (
-	"testing"
-)
-
-func TestNodeTree(t *testing.T) {
-	tree := newNode("/")
-	users := tree.insertNode("users")
-
-	t.Run("check sub nodes", func(t *testing.T) {
-		if tree.kind != nodeStatic {
-			t.Error("node kind parsing error")
-		}
-
-		if users.kind != nodeStatic {
-			t.Error("node kind parsing error")
-		}
-
-		if len(tree.children) != 1 || len(tree.statics) != 1 {
-			t.Error("error inserting a new sub node")
-		}
-	})
-
-	tree.bind(MethodGet, "/", NewPlug(nil))
-	users.bind(MethodPost, "/users", NewPlug(nil))
-
-	t.Run("check endpoints", func(t *testing.T) {
-		var endp *endpoint
-
-		endp = tree.endpoints.Value(MethodGet)
-		if endp == nil {
-			t.Error("error binding method")
-		}
-
-		endp = users.endpoints.Value(MethodPost)
-		if endp == nil {
-			t.Error("error binding method")
-		}
-	})
-
-	avy := users.insertNode("avatar")
-	avy.insertNode(":id")
-
-	t.Run("check node params", func(t *testing.T) {
-		if avy.params == nil {
-			t.Error("error inserting parametric node")
-		}
-	})
-}
+package plugo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNodeTree(t *testing.T) {
+	tree := newNode("/")
+	users := tree.insertNode("users")
+
+	t.Run("check sub nodes", func(t *testing.T) {
+		if tree.kind != nodeStatic {
+			t.Error("node kind parsing error")
+		}
+
+		if users.kind != nodeStatic {
+			t.Error("node kind parsing error")
+		}
+
+		if len(tree.children) != 1 || len(tree.statics) != 1 {
+			t.Error("error inserting a new sub node")
+		}
+	})
+
+	tree.bind(nil, MethodGet, "/", NewPlug(nil))
+	users.bind(nil, MethodPost, "/users", NewPlug(nil))
+
+	t.Run("check endpoints", func(t *testing.T) {
+		var endp *endpoint
+
+		endp = tree.endpoints.Value(MethodGet)
+		if endp == nil {
+			t.Error("error binding method")
+		}
+
+		endp = users.endpoints.Value(MethodPost)
+		if endp == nil {
+			t.Error("error binding method")
+		}
+	})
+
+	avy := users.insertNode("avatar")
+	avy.insertNode(":id")
+
+	t.Run("check node params", func(t *testing.T) {
+		if avy.params == nil {
+			t.Error("error inserting parametric node")
+		}
+	})
+}
+
+func TestStaticChains(t *testing.T) {
+	router := New()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(RouteContext(r.Context()).Pattern))
+	}
+
+	router.Get("/repos/:owner/git/refs/heads", handler)
+
+	git := router.routes.statics[0].params.statics[0]
+	if git.label != "git/refs/heads" || git.segments != 3 {
+		t.Fatalf("got chain '%s' with %d segments want 'git/refs/heads' with 3", git.label, git.segments)
+	}
+
+	router.Get("/repos/:owner/git/refs", handler)
+	router.Get("/repos/:owner/git/tags/:sha", handler)
+	router.Get("/repos/:owner/gists", handler)
+
+	owner := router.routes.statics[0].params
+	if len(owner.statics) != 2 || string(owner.indices) != "gg" {
+		t.Fatalf("got %d static children indexed by '%s' want 2 indexed by 'gg'", len(owner.statics), owner.indices)
+	}
+
+	git = owner.statics[0]
+	if git.label != "git" || len(git.statics) != 2 || git.statics[0].label != "refs" || git.statics[0].statics[0].label != "heads" {
+		t.Fatalf("unexpected split of the chain '%s'", git.label)
+	}
+
+	var tests = []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/repos/plugo/git/refs/heads", http.StatusOK, "/repos/:owner/git/refs/heads"},
+		{"/repos/plugo/git/refs", http.StatusOK, "/repos/:owner/git/refs"},
+		{"/repos/plugo/git/tags/abc", http.StatusOK, "/repos/:owner/git/tags/:sha"},
+		{"/repos/plugo/gists", http.StatusOK, "/repos/:owner/gists"},
+		{"/repos/plugo/git", http.StatusNotFound, "404 page not found\n"},
+		{"/repos/plugo/git/refs/tails", http.StatusNotFound, "404 page not found\n"},
+		{"/repos/plugo/git/ref", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s got %d '%s' want %d '%s'", test.path, w.Code, w.Body.String(), test.code, test.body)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package plugo
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+	"unicode"
+)
+
+// ParamType validates the value of a parametric segment with a type constraint,
+// like :id<int>, returning the parsed value and whether it is valid.
+type ParamType func(value string) (parsed any, ok bool)
+
+// UUID is a parsed universally unique identifier.
+type UUID [16]byte
+
+// String returns the canonical form of the UUID, like 123e4567-e89b-12d3-a456-426614174000.
+func (u UUID) String() string {
+	var buf [36]byte
+
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+
+	return string(buf[:])
+}
+
+// RegisterParamType adds a type constraint for the parametric segments of the
+// routes registered after it, replacing any type with the same name.
+func (rt *Router) RegisterParamType(name string, typ ParamType) {
+	if !isParamName(name) || typ == nil {
+		panic(fmt.Errorf("%w: %s", ErrInvalidParamType, name))
+	}
+
+	rt.paramTypes[name] = typ
+}
+
+// checkParamType verifies that the type constraint of a segment is registered.
+func (rt *Router) checkParamType(segment string) error {
+	var types []string
+
+	switch parseStringToNodeType(segment) {
+	case nodeParam:
+		_, typ := splitParamSegment(segment)
+		types = append(types, typ)
+
+	case nodeComposite:
+		for _, part := range parseCompositeSegment(segment) {
+			types = append(types, part.typ)
+		}
+	}
+
+	for _, typ := range types {
+		if _, ok := rt.paramTypes[typ]; typ != "" && !ok {
+			return fmt.Errorf("%w: %s in %s", ErrUnknownParamType, typ, segment)
+		}
+	}
+
+	return nil
+}
+
+// defaultParamTypes returns the built-in type constraints.
+func defaultParamTypes() map[string]ParamType {
+	return map[string]ParamType{
+		"int":   parseIntParam,
+		"uint":  parseUintParam,
+		"float": parseFloatParam,
+		"bool":  parseBoolParam,
+		"uuid":  parseUUIDParam,
+		"alpha": parseAlphaParam,
+		"date":  parseDateParam,
+	}
+}
+
+func parseIntParam(value string) (any, bool) {
+	n, err := strconv.Atoi(value)
+	return n, err == nil
+}
+
+func parseUintParam(value string) (any, bool) {
+	n, err := strconv.ParseUint(value, 10, 0)
+	return uint(n), err == nil
+}
+
+func parseFloatParam(value string) (any, bool) {
+	n, err := strconv.ParseFloat(value, 64)
+	return n, err == nil
+}
+
+func parseBoolParam(value string) (any, bool) {
+	b, err := strconv.ParseBool(value)
+	return b, err == nil
+}
+
+func parseUUIDParam(value string) (any, bool) {
+	var u UUID
+
+	if len(value) != 36 || value[8] != '-' || value[13] != '-' || value[18] != '-' || value[23] != '-' {
+		return u, false
+	}
+
+	digits := value[0:8] + value[9:13] + value[14:18] + value[19:23] + value[24:]
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return u, false
+	}
+
+	return u, true
+}
+
+func parseAlphaParam(value string) (any, bool) {
+	for _, c := range value {
+		if !unicode.IsLetter(c) {
+			return value, false
+		}
+	}
+
+	return value, value != ""
+}
+
+func parseDateParam(value string) (any, bool) {
+	t, err := time.Parse("2006-01-02", value)
+	return t, err == nil
+}
@@ -0,0 +1,84 @@
+package plugo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParamTypes(t *testing.T) {
+	router := New()
+	router.RegisterParamType("hex", func(value string) (any, bool) {
+		var n int
+		_, err := fmt.Sscanf(value, "%x", &n)
+		return n, err == nil && strings.Trim(value, "0123456789abcdef") == ""
+	})
+
+	router.GetC("/users/:id<int>", func(conn Connection) error {
+		id, _ := conn.ParamInt("id")
+		return conn.String(http.StatusOK, "int %d", id+1)
+	})
+	router.GetC("/users/:name<alpha>", func(conn Connection) error {
+		name, _ := conn.Param("name")
+		return conn.String(http.StatusOK, "alpha %s", name)
+	})
+	router.GetC("/sessions/:sid<uuid>", func(conn Connection) error {
+		sid, _ := conn.ParamUUID("sid")
+		return conn.String(http.StatusOK, "uuid %s", sid)
+	})
+	router.GetC("/reports/:day<date>/:ratio<float>/:ok<bool>", func(conn Connection) error {
+		day, _ := conn.ParamDate("day")
+		ratio, _ := conn.ParamFloat("ratio")
+		ok, _ := conn.ParamBool("ok")
+		return conn.String(http.StatusOK, "%s %.1f %t", day.Format("Jan 2"), ratio, ok)
+	})
+	router.GetC("/colors/:color<hex>", func(conn Connection) error {
+		color, _ := conn.ParamValue("color")
+		return conn.String(http.StatusOK, "hex %d", color)
+	})
+	router.GetC("/pages/:page", func(conn Connection) error {
+		page, ok := conn.ParamUint("page")
+		return conn.String(http.StatusOK, "page %d %t", page, ok)
+	})
+
+	var tests = []struct {
+		name string
+		path string
+		code int
+		body string
+	}{
+		{"int", "/users/41", http.StatusOK, "int 42"},
+		{"fall through", "/users/alice", http.StatusOK, "alpha alice"},
+		{"not found", "/users/a1", http.StatusNotFound, "404 page not found\n"},
+		{"uuid", "/sessions/123E4567-E89B-12D3-A456-426614174000", http.StatusOK, "uuid 123e4567-e89b-12d3-a456-426614174000"},
+		{"invalid uuid", "/sessions/123e4567", http.StatusNotFound, "404 page not found\n"},
+		{"several types", "/reports/2023-04-01/0.5/true", http.StatusOK, "Apr 1 0.5 true"},
+		{"invalid date", "/reports/2023-13-01/0.5/true", http.StatusNotFound, "404 page not found\n"},
+		{"custom type", "/colors/ff", http.StatusOK, "hex 255"},
+		{"untyped parsed", "/pages/3", http.StatusOK, "page 3 true"},
+		{"untyped invalid", "/pages/x", http.StatusOK, "page 0 false"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s got %d '%s' want %d '%s'", test.name, w.Code, w.Body.String(), test.code, test.body)
+		}
+	}
+
+	t.Run("unknown type", func(t *testing.T) {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.Is(err, ErrUnknownParamType) {
+				t.Errorf("got panic %v want %v", err, ErrUnknownParamType)
+			}
+		}()
+
+		router.Get("/items/:id<nope>", func(w http.ResponseWriter, r *http.Request) {})
+	})
+}
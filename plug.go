@@ -1,20 +1,23 @@
-package plugo
-
-import (
-	"net/http"
-)
-
-// Plug represents a handler for an specific route
-type Plug struct {
-	serve http.HandlerFunc
-}
-
-var _ http.Handler = &Plug{}
-
-func NewPlug(serve http.HandlerFunc) *Plug {
-	return &Plug{serve}
-}
-
-func (p *Plug) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	p.serve(w, r)
-}
+package plugo
+
+import (
+	"net/http"
+)
+
+// Plug represents a handler for an specific route
+type Plug struct {
+	serve http.HandlerFunc
+
+	// handler adapted to serve, to describe the routes
+	source any
+}
+
+var _ http.Handler = &Plug{}
+
+func NewPlug(serve http.HandlerFunc) *Plug {
+	return &Plug{serve: serve}
+}
+
+func (p *Plug) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.serve(w, r)
+}
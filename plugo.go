@@ -1,270 +1,904 @@
-package plugo
-
-import (
-	"context"
-	"net/http"
-	"strings"
-)
-
-// RouterOption represents a handler for setting Plug configurable parameters.
-type RouterOption func(*RouterConfig)
-
-// Router represents an HTTP router.
-type Router struct {
-	// node tree
-	routes *node
-
-	// static nodes
-	namedRoutes map[string]*node
-
-	// slice of middlewares to execute after a request
-	middlewares []MiddlewareFunc
-
-	// public fields to configurate
-	*RouterConfig
-}
-
-// New creates a new Router pointer with a default set of options
-func New(opts ...RouterOption) *Router {
-	return NewRouter(opts...)
-}
-
-// NewRouter creates a new Router pointer with a default set of options
-func NewRouter(opts ...RouterOption) *Router {
-	config := &RouterConfig{}
-	DefaultRouterOptions(config)
-	for _, opt := range opts {
-		opt(config)
-	}
-
-	router := &Router{
-		routes:       newNode(config.IndexPath),
-		namedRoutes:  make(map[string]*node),
-		middlewares:  make([]MiddlewareFunc, 0),
-		RouterConfig: config,
-	}
-
-	return router
-}
-
-// RouterConfig is a set of public fields to configurate a Router
-type RouterConfig struct {
-	//
-	IndexPath string
-
-	// strick check for '/' at the end of a route
-	SlashStrictly bool
-
-	// 404 not found handler
-	NotFound http.HandlerFunc
-
-	// 405 method not allowed handler
-	MethodNotAllowed http.HandlerFunc
-}
-
-func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// handling the current request
-	route, endp, handler := rt.findRequestRoute(r)
-	if route != nil {
-		if endp != nil {
-			r = r.WithContext(context.WithValue(context.Background(), MethodID("pattern"), endp.pattern))
-		}
-
-		var middlewareFail error
-
-		rt.handleMiddlewares(w, r, &middlewareFail, route.middlewares...)
-		if middlewareFail != nil {
-			return
-		}
-	}
-
-	handler.ServeHTTP(w, r)
-}
-
-// Use adds a set of middlewares to be executed before a request.
-func (rt *Router) Use(middlewares ...MiddlewareFunc) {
-	rt.middlewares = append(rt.middlewares, middlewares...)
-}
-
-// Get registers a new HTTP GET method handler.
-func (rt *Router) Get(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) {
-	rt.HandleFunc(MethodGet, pattern, handler, middlewares...)
-}
-
-// Post registers a new HTTP POST method handler.
-func (rt *Router) Post(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) {
-	rt.HandleFunc(MethodPost, pattern, handler, middlewares...)
-}
-
-// Put registers a new HTTP PUT method handler.
-func (rt *Router) Put(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) {
-	rt.HandleFunc(MethodPut, pattern, handler, middlewares...)
-}
-
-// Delete registers a new HTTP DELETE method handler.
-func (rt *Router) Delete(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) {
-	rt.HandleFunc(MethodDelete, pattern, handler, middlewares...)
-}
-
-// Connect registers a new HTTP CONNECT method handler.
-func (rt *Router) Connect(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) {
-	rt.HandleFunc(MethodConnect, pattern, handler, middlewares...)
-}
-
-// Head registers a new HTTP HEAD method handler.
-func (rt *Router) Head(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) {
-	rt.HandleFunc(MethodHead, pattern, handler, middlewares...)
-}
-
-// Options registers a new HTTP OPTIONS method handler.
-func (rt *Router) Options(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) {
-	rt.HandleFunc(MethodOptions, pattern, handler, middlewares...)
-}
-
-// Trace registers a new HTTP TRACE method handler.
-func (rt *Router) Trace(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) {
-	rt.HandleFunc(MethodTrace, pattern, handler, middlewares...)
-}
-
-// Handle registers a new handler to serve http requests in the provided method.
-func (rt *Router) Handle(method MethodID, pattern string, handler http.Handler, middlewares ...MiddlewareFunc) {
-	if !method.Allowed() {
-		panic(ErrMethodNotAllowed)
-	}
-
-	if strings.HasSuffix(rt.IndexPath, "/") {
-		pattern = rt.IndexPath[0:len(rt.IndexPath)-1] + pattern
-	} else {
-		pattern = rt.IndexPath + pattern
-	}
-
-	var isStatic bool = true
-
-	// slice of elements splited according to whether slash strictly is true or false
-	cleaned := cleanPath(pattern)
-	moves := rt.parsePatternToMovements(cleaned)
-	// initial node of the tree
-	root := rt.routes
-	for _, move := range moves {
-		if parseStringToNodeType(move) != nodeStatic {
-			isStatic = false
-		}
-
-		ok := root.match(move)
-		if ok {
-			continue
-		}
-
-		aux := root.findRoute(move)
-		// if it exists, then take it as the current root
-		if aux != nil {
-			root = aux
-		} else {
-			// if it does not exist, then create it in the current root
-			root = root.insertNode(move)
-		}
-	}
-
-	root.bind(method, pattern, NewPlug(handler.ServeHTTP))
-	root.use(middlewares...)
-
-	if isStatic {
-		rt.namedRoutes[cleaned] = root
-	}
-}
-
-// HandleFunc registers a new handler function to serve http requests in the provided method.
-func (rt *Router) HandleFunc(method MethodID, pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) {
-	rt.Handle(method, pattern, NewPlug(handler))
-}
-
-func (rt *Router) findRequestRoute(r *http.Request) (*node, *endpoint, http.Handler) {
-	route, staticOk := rt.namedRoutes[cleanPath(r.URL.Path)]
-	if staticOk {
-		ent := route.endpoints.Value(MethodID(r.Method))
-		if ent == nil {
-			return nil, nil, NewPlug(rt.MethodNotAllowed)
-		}
-
-		return route, ent, ent.handler
-	}
-
-	// steps to search a determinate path
-	moves := rt.parsePatternToMovements(cleanPath(r.URL.Path))
-	// search for node
-	root := rt.routes
-	for _, move := range moves {
-		if root != nil {
-			root = root.findRoute(move)
-		}
-	}
-
-	if root != nil {
-		ent := root.endpoints.Value(MethodID(r.Method))
-		if ent != nil {
-			return root, ent, ent.handler
-		}
-
-		if root.catchAll != nil {
-			ent = root.catchAll.endpoints.Value(MethodID(r.Method))
-			if ent != nil {
-				return root, ent, ent.handler
-			} else {
-				return nil, nil, NewPlug(rt.MethodNotAllowed)
-			}
-		}
-	}
-
-	return nil, nil, NewPlug(rt.NotFound)
-}
-
-func (rt *Router) handleMiddlewares(w http.ResponseWriter, r *http.Request, fail *error, middlewares ...MiddlewareFunc) {
-	handlers := make([]MiddlewareFunc, 0)
-	handlers = append(handlers, rt.middlewares...)
-	handlers = append(handlers, middlewares...)
-
-	for _, handler := range handlers {
-		handler(fail)(w, r)
-		if *fail != nil {
-			break
-		}
-	}
-}
-
-// parsePatternToMovements splits a pattern depending on whether slashStrictly is true or false.
-func (rt *Router) parsePatternToMovements(pattern string) []string {
-	var moves = make([]string, 0)
-
-	if rt.SlashStrictly {
-		moves = strings.SplitAfter(pattern, "/")
-	} else {
-		moves = strings.Split(pattern, "/")
-		moves[0] = "/"
-	}
-
-	if strings.HasSuffix(pattern, "/") {
-		moves = moves[:len(moves)-1]
-	}
-
-	return moves
-}
-
-// DefaultPlugOptions sets an basic configuration for the new Plug object.
-func DefaultRouterOptions(rt *RouterConfig) {
-	rt.IndexPath = "/"
-
-	rt.SlashStrictly = false
-
-	rt.NotFound = defaultNotFound
-
-	rt.MethodNotAllowed = defaultMethodNotAllowed
-}
-
-func defaultMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(405)
-	w.Write([]byte("Method not allowed."))
-}
-
-func defaultNotFound(w http.ResponseWriter, r *http.Request) {
-	http.NotFound(w, r)
-}
+package plugo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RouterOption represents a handler for setting Plug configurable parameters.
+type RouterOption func(*RouterConfig)
+
+// Router represents an HTTP router.
+type Router struct {
+	// node tree
+	routes *node
+
+	// static nodes
+	namedRoutes map[string]*node
+
+	// routes identified by a name
+	routeNames map[string]*Route
+
+	// type constraints available for parametric segments
+	paramTypes map[string]ParamType
+
+	// extension methods registered with RegisterMethod
+	methods map[MethodID]bool
+
+	// slice of middlewares to execute before a request
+	middlewares []MiddlewareFunc
+
+	// prefix prepended to every pattern registered through a group
+	prefix string
+
+	// parent router of a group, nil for the root router
+	parent *Router
+
+	// conditions of the routes registered through a group created with When
+	matchers []Matcher
+
+	// API version of the routes registered through a group created with Version
+	version *apiVersion
+
+	// names of the versions created with Version
+	versions []string
+
+	// routers created with Host, sorted by priority
+	hosts []*hostRoute
+
+	// public fields to configurate
+	*RouterConfig
+}
+
+// New creates a new Router pointer with a default set of options
+func New(opts ...RouterOption) *Router {
+	return NewRouter(opts...)
+}
+
+// NewRouter creates a new Router pointer with a default set of options
+func NewRouter(opts ...RouterOption) *Router {
+	config := &RouterConfig{}
+	DefaultRouterOptions(config)
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	router := &Router{
+		routes:       newNode(config.IndexPath),
+		namedRoutes:  make(map[string]*node),
+		routeNames:   make(map[string]*Route),
+		paramTypes:   defaultParamTypes(),
+		methods:      make(map[MethodID]bool),
+		middlewares:  make([]MiddlewareFunc, 0),
+		RouterConfig: config,
+	}
+
+	return router
+}
+
+// RouterConfig is a set of public fields to configurate a Router
+type RouterConfig struct {
+	//
+	IndexPath string
+
+	// strick check for '/' at the end of a route
+	SlashStrictly bool
+
+	// redirects the requests without a route to the same path with or without
+	// the trailing slash if it has one, only used with SlashStrictly
+	RedirectTrailingSlash bool
+
+	// redirects the requests to a path that is not clean, like /a//b/../c,
+	// to the cleaned path instead of serving them with its route
+	RedirectFixedPath bool
+
+	// matches the static segments of the routes ignoring the case of ASCII
+	// letters, the values of the parameters keep their case
+	CaseInsensitive bool
+
+	// redirects the requests matched by CaseInsensitive to the path with the
+	// case of the static segments of the matched route
+	RedirectCanonicalCase bool
+
+	// routes on the escaped path of the request, so an encoded slash (%2F) is
+	// part of a segment instead of a separator, unescaping the values of the
+	// parameters
+	UseEscapedPath bool
+
+	// responds with a 400 status to the requests whose path has malformed
+	// escapes, only used with UseEscapedPath
+	StrictEscaping bool
+
+	// exposes the named capture groups of regexp segments, like {(?P<year>[0-9]{4})-[0-9]{2}}, as parameters
+	RegexpGroups bool
+
+	// 404 not found handler
+	NotFound http.HandlerFunc
+
+	// 405 method not allowed handler, the Allow header and the AllowedMethods
+	// of the route context are set before calling it
+	MethodNotAllowed http.HandlerFunc
+
+	// answers the OPTIONS requests of the paths without an OPTIONS route
+	HandleOptions bool
+
+	// serves the HEAD requests of the paths without a HEAD route with their
+	// GET route, discarding the body of the response
+	HandleHead bool
+
+	// handler of the automatic OPTIONS responses, the Allow header and the
+	// AllowedMethods of the route context are set before calling it
+	OptionsHandler http.HandlerFunc
+
+	// handler for the errors returned by a HandlerFunc
+	ErrorHandler func(conn Connection, err error)
+
+	// reports duplicate routes, parameters with different names at the same
+	// position and unreachable routes as errors when registering them
+	StrictRoutes bool
+
+	// prefix of the path segment that selects a version, like /v for
+	// /v2/items, the routes of the versions are not registered with it if empty
+	VersionPrefix string
+
+	// header with the version requested by a client, like Accept-Version: 2
+	VersionHeader string
+
+	// vendor of the media types of the Accept header that request a version,
+	// like acme for application/vnd.acme.v2+json, disabled if empty
+	VersionVendor string
+
+	// version of the requests that do not request one, the latest version if empty
+	DefaultVersion string
+
+	// 406 not acceptable handler, for the requests whose version has no route
+	NotAcceptable http.HandlerFunc
+}
+
+var routeContextPool = sync.Pool{
+	New: func() any {
+		return &RoutingContext{}
+	},
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// groups share the tree of the root router
+	if rt.parent != nil {
+		rt.root().ServeHTTP(w, r)
+		return
+	}
+
+	// reuses the routing context of outer handlers and routers
+	rctx := RouteContext(r.Context())
+	if rctx == nil {
+		rctx = routeContextPool.Get().(*RoutingContext)
+		rctx.reset()
+		defer routeContextPool.Put(rctx)
+
+		r = r.WithContext(context.WithValue(r.Context(), routeContextKey, rctx))
+	}
+
+	// the routes of a Host router serve the requests of its hosts
+	if len(rt.hosts) > 0 {
+		if host := rt.matchHost(r.Host, rctx); host != nil {
+			host.serveRequest(w, r, rctx)
+			return
+		}
+	}
+
+	rt.serveRequest(w, r, rctx)
+}
+
+// serveRequest serves a request with the routes of rt.
+func (rt *Router) serveRequest(w http.ResponseWriter, r *http.Request, rctx *RoutingContext) {
+	raw := rt.requestPath(r)
+	path := cleanPath(raw)
+	route, endp, handler := rt.findRequestRoute(r, path, rctx)
+	if location, ok := rt.redirectPath(r, raw, path, route, endp, rctx); ok {
+		rt.redirect(w, r, location)
+		return
+	}
+
+	if endp == nil && len(rctx.AllowedMethods) > 0 {
+		w.Header().Set("Allow", strings.Join(rctx.AllowedMethods, ", "))
+	}
+	if route != nil && endp != nil {
+		rctx.Pattern = endp.routePattern
+		rctx.Name = endp.name
+		rctx.Metadata = endp.metadata
+		rctx.addParams(endp.paramKeys)
+		if rt.RegexpGroups {
+			rctx.addGroups(endp.groups)
+		}
+	}
+
+	// HEAD requests served by a GET route only send the headers
+	if endp != nil && endp.method == MethodGet && r.Method == http.MethodHead {
+		head := &headResponse{ResponseWriter: w}
+		rt.serve(head, r, route, endp, handler)
+		head.finish()
+		return
+	}
+
+	rt.serve(w, r, route, endp, handler)
+}
+
+// serve runs the middlewares of the matched route and its handler.
+func (rt *Router) serve(w http.ResponseWriter, r *http.Request, route *node, endp *endpoint, handler http.Handler) {
+	if route != nil && endp != nil {
+		if chain := endp.router.middlewareChain(endp.middlewares...); len(chain) > 0 {
+			var middlewareFail error
+
+			rt.handleMiddlewares(w, r, &middlewareFail, chain...)
+			if middlewareFail != nil {
+				return
+			}
+		}
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// Use adds a set of middlewares to be executed before a request.
+func (rt *Router) Use(middlewares ...MiddlewareFunc) {
+	rt.middlewares = append(rt.middlewares, middlewares...)
+}
+
+// Get registers a new HTTP GET method handler.
+func (rt *Router) Get(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleFunc(MethodGet, pattern, handler, middlewares...)
+}
+
+// Post registers a new HTTP POST method handler.
+func (rt *Router) Post(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleFunc(MethodPost, pattern, handler, middlewares...)
+}
+
+// Put registers a new HTTP PUT method handler.
+func (rt *Router) Put(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleFunc(MethodPut, pattern, handler, middlewares...)
+}
+
+// Patch registers a new HTTP PATCH method handler.
+func (rt *Router) Patch(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleFunc(MethodPatch, pattern, handler, middlewares...)
+}
+
+// Delete registers a new HTTP DELETE method handler.
+func (rt *Router) Delete(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleFunc(MethodDelete, pattern, handler, middlewares...)
+}
+
+// Connect registers a new HTTP CONNECT method handler.
+func (rt *Router) Connect(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleFunc(MethodConnect, pattern, handler, middlewares...)
+}
+
+// Head registers a new HTTP HEAD method handler.
+func (rt *Router) Head(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleFunc(MethodHead, pattern, handler, middlewares...)
+}
+
+// Options registers a new HTTP OPTIONS method handler.
+func (rt *Router) Options(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleFunc(MethodOptions, pattern, handler, middlewares...)
+}
+
+// Trace registers a new HTTP TRACE method handler.
+func (rt *Router) Trace(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleFunc(MethodTrace, pattern, handler, middlewares...)
+}
+
+// GetC registers a new HTTP GET method handler that receives a Connection.
+func (rt *Router) GetC(pattern string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleC(MethodGet, pattern, handler, middlewares...)
+}
+
+// PostC registers a new HTTP POST method handler that receives a Connection.
+func (rt *Router) PostC(pattern string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleC(MethodPost, pattern, handler, middlewares...)
+}
+
+// PutC registers a new HTTP PUT method handler that receives a Connection.
+func (rt *Router) PutC(pattern string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleC(MethodPut, pattern, handler, middlewares...)
+}
+
+// PatchC registers a new HTTP PATCH method handler that receives a Connection.
+func (rt *Router) PatchC(pattern string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleC(MethodPatch, pattern, handler, middlewares...)
+}
+
+// DeleteC registers a new HTTP DELETE method handler that receives a Connection.
+func (rt *Router) DeleteC(pattern string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleC(MethodDelete, pattern, handler, middlewares...)
+}
+
+// ConnectC registers a new HTTP CONNECT method handler that receives a Connection.
+func (rt *Router) ConnectC(pattern string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleC(MethodConnect, pattern, handler, middlewares...)
+}
+
+// HeadC registers a new HTTP HEAD method handler that receives a Connection.
+func (rt *Router) HeadC(pattern string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleC(MethodHead, pattern, handler, middlewares...)
+}
+
+// OptionsC registers a new HTTP OPTIONS method handler that receives a Connection.
+func (rt *Router) OptionsC(pattern string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleC(MethodOptions, pattern, handler, middlewares...)
+}
+
+// TraceC registers a new HTTP TRACE method handler that receives a Connection.
+func (rt *Router) TraceC(pattern string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.HandleC(MethodTrace, pattern, handler, middlewares...)
+}
+
+// Any registers a new handler for every standard method and the extension
+// methods registered in the router so far.
+func (rt *Router) Any(pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.Match(rt.anyMethods(), pattern, handler, middlewares...)
+}
+
+// Match registers a new handler for several methods at once, sharing the
+// name and metadata of the returned route.
+// It panics with a *RouteError if the route can not be registered.
+func (rt *Router) Match(methods []MethodID, pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	route, err := rt.tryHandle(methods, pattern, NewPlug(handler), middlewares...)
+	if err != nil {
+		panic(err)
+	}
+
+	return route
+}
+
+// Handle registers a new handler to serve http requests in the provided method.
+// Optional parts of the pattern, like /posts/:page? or /archive(/:year(/:month)),
+// register the route once for every combination of them. The middlewares only
+// run for the requests served by the route, after the ones of the router.
+// It panics with a *RouteError if the route can not be registered, see TryHandle.
+func (rt *Router) Handle(method MethodID, pattern string, handler http.Handler, middlewares ...MiddlewareFunc) *Route {
+	route, err := rt.TryHandle(method, pattern, handler, middlewares...)
+	if err != nil {
+		panic(err)
+	}
+
+	return route
+}
+
+// TryHandle registers a new handler like Handle, returning a *RouteError
+// instead of panicking if the method is not allowed, the pattern is malformed
+// or, with StrictRoutes, the route conflicts with the registered ones.
+// Nothing is registered when an error is returned.
+func (rt *Router) TryHandle(method MethodID, pattern string, handler http.Handler, middlewares ...MiddlewareFunc) (*Route, error) {
+	return rt.tryHandle([]MethodID{method}, pattern, handler, middlewares...)
+}
+
+// tryHandle registers the handler in every method after validating all of them.
+func (rt *Router) tryHandle(methods []MethodID, path string, handler http.Handler, middlewares ...MiddlewareFunc) (*Route, error) {
+	pattern := joinPaths(rt.IndexPath, joinPaths(rt.prefix, path))
+
+	if len(methods) == 0 {
+		return nil, &RouteError{Pattern: pattern, Offset: -1, Err: ErrMethodNotAllowed}
+	}
+
+	for _, method := range methods {
+		if !rt.allowsMethod(method) {
+			return nil, &RouteError{Method: method, Pattern: pattern, Offset: -1, Err: ErrMethodNotAllowed}
+		}
+	}
+
+	variants, err := expandOptionalPattern(pattern)
+	if err != nil {
+		return nil, &RouteError{Method: methods[0], Pattern: pattern, Offset: -1, Err: err}
+	}
+
+	for _, method := range methods {
+		for _, variant := range variants {
+			if err := rt.checkPattern(method, variant); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	route := &Route{
+		router:   rt,
+		methods:  methods,
+		pattern:  pattern,
+		variants: variants,
+	}
+
+	// the routes of a version are selected by the request without a prefix,
+	// and by the path with the prefix of the version
+	matchers := rt.matchers
+	var prefixed []string
+	if rt.version != nil {
+		matchers = append(append(make([]Matcher, 0, len(rt.matchers)+1), rt.matchers...), rt.version.matcher)
+
+		if rt.VersionPrefix != "" {
+			// the variants of the pattern were validated with the same segments
+			prefixed, _ = expandOptionalPattern(joinPaths(rt.IndexPath, joinPaths(rt.version.prefix, path)))
+
+			// the URL of the route selects its version without a header
+			route.variants = prefixed
+		}
+	}
+
+	for _, method := range methods {
+		for _, variant := range variants {
+			endp := rt.handle(method, variant, matchers, handler, middlewares...)
+			endp.routePattern = pattern
+			if rt.version != nil {
+				endp.version = rt.version.name
+			}
+
+			route.endpoints = append(route.endpoints, endp)
+		}
+
+		for _, variant := range prefixed {
+			endp := rt.handle(method, variant, rt.matchers, handler, middlewares...)
+			endp.routePattern = joinPaths(rt.IndexPath, joinPaths(rt.version.prefix, path))
+			route.endpoints = append(route.endpoints, endp)
+		}
+	}
+
+	return route, nil
+}
+
+// checkPattern validates a pattern without optional parts before registering
+// it in method, which is empty for mounts.
+func (rt *Router) checkPattern(method MethodID, pattern string) error {
+	cleaned := cleanPath(pattern)
+	moves := rt.parsePatternToMovements(cleaned)
+
+	// existing node for the moves checked so far, nil once the route diverges
+	// from the tree, and the number of its moves left to check
+	root := rt.routes
+	pending := 0
+	offset := 1
+	for i, move := range moves {
+		fail := &RouteError{Method: method, Pattern: cleaned, Segment: move, Offset: offset}
+		offset += len(move) + 1
+
+		if fail.Err = rt.checkParamType(move); fail.Err != nil {
+			return fail
+		}
+
+		kind := parseStringToNodeType(move)
+		if kind == nodeRegexp {
+			if _, err := compileSegmentRegexp(move); err != nil {
+				fail.Err = fmt.Errorf("%w: %v", ErrPatternNotCompile, err)
+				return fail
+			}
+		}
+
+		if !rt.StrictRoutes {
+			continue
+		}
+
+		// catch all segments take the rest of the path
+		if kind == nodeCatchAll && i < len(moves)-1 {
+			fail.Err = ErrUnreachableRoute
+			return fail
+		}
+
+		// nodes with a chain of static segments take several moves
+		if root != nil && pending == 0 {
+			root, pending = root.lookup(moves[i:])
+		}
+		pending--
+
+		if root != nil && strings.Join(root.keys, ",") != strings.Join(parseParamKeysFromPattern(move), ",") {
+			fail.Err = fmt.Errorf("%w: %s and %s", ErrAmbiguousParam, move, strings.Join(root.keys, ", "))
+			return fail
+		}
+	}
+
+	// routes with matchers are alternatives to the others
+	if rt.StrictRoutes && method != "" && len(rt.matchers) == 0 && rt.version == nil && root != nil && pending <= 0 && root.endpoints.Value(method) != nil {
+		return &RouteError{Method: method, Pattern: cleaned, Offset: -1, Err: ErrDuplicateRoute}
+	}
+
+	return nil
+}
+
+// handle registers the handler for a pattern without optional parts.
+func (rt *Router) handle(method MethodID, pattern string, matchers []Matcher, handler http.Handler, middlewares ...MiddlewareFunc) *endpoint {
+	var isStatic bool = true
+
+	// slice of elements splited according to whether slash strictly is true or false
+	cleaned := cleanPath(pattern)
+	moves := rt.parsePatternToMovements(cleaned)
+	for _, move := range moves {
+		if parseStringToNodeType(move) != nodeStatic {
+			isStatic = false
+		}
+	}
+
+	root := rt.insertMovements(moves)
+
+	endp := root.bind(rt, method, pattern, NewPlug(handler.ServeHTTP), matchers...)
+	endp.source = handler
+	endp.paramKeys = parseParamKeysFromPattern(cleaned)
+	endp.groups = parseRegexpGroups(moves)
+	endp.middlewares = middlewares
+
+	if isStatic {
+		rt.namedRoutes[unescapeStatic(cleaned)] = root
+	}
+
+	return endp
+}
+
+// HandleFunc registers a new handler function to serve http requests in the provided method.
+func (rt *Router) HandleFunc(method MethodID, pattern string, handler http.HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.Handle(method, pattern, NewPlug(handler), middlewares...)
+}
+
+// HandleC registers a new handler that receives a Connection to serve http requests
+// in the provided method. Non-nil errors returned by the handler are sent to the
+// ErrorHandler of the router.
+func (rt *Router) HandleC(method MethodID, pattern string, handler HandlerFunc, middlewares ...MiddlewareFunc) *Route {
+	return rt.Handle(method, pattern, &Plug{serve: rt.Adapt(handler), source: handler}, middlewares...)
+}
+
+// Adapt converts a HandlerFunc to an http.HandlerFunc that reports the returned
+// errors to the ErrorHandler of the router.
+func (rt *Router) Adapt(handler HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn := newConnection(w, r)
+
+		if err := handler(conn); err != nil && rt.ErrorHandler != nil {
+			rt.ErrorHandler(conn, err)
+		}
+	}
+}
+
+// insertMovements walks the tree following moves, creating the missing nodes,
+// and returns the last one.
+func (rt *Router) insertMovements(moves []string) *node {
+	// initial node of the tree
+	root := rt.routes
+	for i := 0; i < len(moves); i++ {
+		move := moves[i]
+
+		// chains of static segments share a node until a route ends or branches in them
+		if n := staticSegments(moves[i:]); n > 0 {
+			statics := unescapeStatics(moves[i : i+n])
+			child, k := root.staticChild(statics)
+			switch {
+			case child == nil:
+				child = root.insertNode(move)
+				child.label = strings.Join(statics, "/")
+				child.segments, k = n, n
+
+			case k < child.segments:
+				child = child.split(k)
+			}
+
+			root = child
+			i += k - 1
+			continue
+		}
+
+		aux := root.findNode(move)
+		// if it exists, then take it as the current root
+		if aux != nil {
+			root = aux
+		} else {
+			// if it does not exist, then create it in the current root
+			root = root.insertNode(move)
+			root.keys = parseParamKeysFromPattern(move)
+
+			if _, typ := splitParamSegment(move); root.kind == nodeParam && typ != "" {
+				root.check = rt.paramTypes[typ]
+			}
+
+			if root.kind == nodeComposite {
+				for _, part := range parseCompositeSegment(move) {
+					if part.name != "" {
+						root.checks = append(root.checks, rt.paramTypes[part.typ])
+					}
+				}
+			}
+		}
+	}
+
+	return root
+}
+
+// requestPath returns the path of the request used to route it, which is
+// escaped if UseEscapedPath is true.
+func (rt *Router) requestPath(r *http.Request) string {
+	if !rt.UseEscapedPath {
+		return r.URL.Path
+	}
+
+	// RawPath is used even if it is malformed, to reject it with StrictEscaping
+	if r.URL.RawPath != "" {
+		return r.URL.RawPath
+	}
+
+	return r.URL.EscapedPath()
+}
+
+// findRequestRoute searches the node and endpoint for the method and the
+// cleaned path of a request, collecting the values of the parametric segments in rctx.
+func (rt *Router) findRequestRoute(r *http.Request, path string, rctx *RoutingContext) (*node, *endpoint, http.Handler) {
+	method := MethodID(r.Method)
+	rctx.pop(0)
+	rctx.moves = rt.appendMovements(rctx.moves[:0], path)
+	rctx.AllowedMethods = rctx.AllowedMethods[:0]
+
+	// the segments of the escaped path are unescaped one by one, so an encoded
+	// slash does not split them
+	moves := rctx.moves
+	if rt.UseEscapedPath {
+		rctx.unescaped = rctx.unescaped[:0]
+		for _, move := range rctx.moves {
+			value, err := url.PathUnescape(move)
+			if err != nil {
+				if rt.StrictEscaping {
+					return nil, nil, NewPlug(defaultBadRequest)
+				}
+
+				value = move
+			}
+
+			rctx.unescaped = append(rctx.unescaped, value)
+		}
+
+		moves = rctx.unescaped
+	}
+
+	search := routeSearch{
+		method:    method,
+		path:      path,
+		moves:     moves,
+		raw:       rctx.moves,
+		unescaped: rt.UseEscapedPath,
+		rctx:      rctx,
+		autoHead:  rt.HandleHead,
+		request:   r,
+		foldCase:  rt.CaseInsensitive,
+	}
+
+	route, staticOk := rt.namedRoutes[path]
+	if staticOk {
+		ent := search.endpointOf(route)
+		if ent != nil {
+			return route, ent, ent.handler
+		}
+	}
+
+	if found := rt.routes.findRoute(&search, 0, 1); found != nil {
+		rctx.AllowedMethods = rctx.AllowedMethods[:0]
+
+		if found.mount == search.endpoint {
+			return found, search.endpoint, stripSegments(search.endpoint.handler, search.depth, rt.UseEscapedPath)
+		}
+
+		return found, search.endpoint, search.endpoint.handler
+	}
+
+	// the path has routes for the method, but not for the requested version
+	if search.notAcceptable {
+		rctx.AllowedMethods = rctx.AllowedMethods[:0]
+		return nil, nil, NewPlug(rt.NotAcceptable)
+	}
+
+	// the path exists but it has no route for the request method
+	if len(rctx.AllowedMethods) > 0 {
+		if rt.HandleOptions && !containsString(rctx.AllowedMethods, http.MethodOptions) {
+			rctx.AllowedMethods = append(rctx.AllowedMethods, http.MethodOptions)
+		}
+		sort.Strings(rctx.AllowedMethods)
+
+		if rt.HandleOptions && method == MethodOptions {
+			return nil, nil, NewPlug(rt.OptionsHandler)
+		}
+
+		return nil, nil, NewPlug(rt.MethodNotAllowed)
+	}
+
+	return nil, nil, NewPlug(rt.NotFound)
+}
+
+// redirectPath returns the canonical form of the request path to redirect the
+// request to, if it is enabled by RedirectFixedPath, RedirectCanonicalCase or
+// RedirectTrailingSlash and only that form has a route for the request method.
+// raw is the request path and route and endp are the node and endpoint found
+// for the cleaned path.
+func (rt *Router) redirectPath(r *http.Request, raw, path string, route *node, endp *endpoint, rctx *RoutingContext) (string, bool) {
+	if endp != nil {
+		fixed := rt.RedirectFixedPath && raw != "" && raw != path
+
+		if rt.CaseInsensitive && rt.RedirectCanonicalCase {
+			if canonical := canonicalPath(route, path, rctx.moves, rt.UseEscapedPath); canonical != path {
+				return canonical, true
+			}
+		}
+
+		return path, fixed
+	}
+
+	// a path with routes for other methods is not a miss
+	if !rt.RedirectTrailingSlash || !rt.SlashStrictly || path == "/" || len(rctx.AllowedMethods) > 0 {
+		return "", false
+	}
+
+	alternate := path + "/"
+	if strings.HasSuffix(path, "/") {
+		alternate = path[:len(path)-1]
+	}
+
+	if _, found, _ := rt.findRequestRoute(r, alternate, rctx); found != nil {
+		return alternate, true
+	}
+
+	// restores the routing data of the request path
+	rt.findRequestRoute(r, path, rctx)
+
+	return "", false
+}
+
+// redirect sends the client to path, keeping the query string and the prefix
+// stripped by mounts, with a 301 status for GET requests and 308 for the rest.
+func (rt *Router) redirect(w http.ResponseWriter, r *http.Request, path string) {
+	code := http.StatusMovedPermanently
+	if r.Method != http.MethodGet {
+		code = http.StatusPermanentRedirect
+	}
+
+	if !rt.UseEscapedPath {
+		path = (&url.URL{Path: path}).EscapedPath()
+	}
+
+	location := (&url.URL{Path: strings.TrimSuffix(MountPath(r), "/")}).EscapedPath() + path
+	if r.URL.RawQuery != "" {
+		location += "?" + r.URL.RawQuery
+	}
+
+	http.Redirect(w, r, location, code)
+}
+
+func (rt *Router) handleMiddlewares(w http.ResponseWriter, r *http.Request, fail *error, middlewares ...MiddlewareFunc) {
+	for _, handler := range middlewares {
+		handler(fail)(w, r)
+		if *fail != nil {
+			break
+		}
+	}
+}
+
+// parsePatternToMovements splits a pattern in segments without the root slash.
+// A trailing slash is kept as a last "/" segment only if slashStrictly is true.
+func (rt *Router) parsePatternToMovements(pattern string) []string {
+	return rt.appendMovements(nil, pattern)
+}
+
+// appendMovements appends the segments of a pattern to moves, like
+// parsePatternToMovements, reusing its capacity.
+func (rt *Router) appendMovements(moves []string, pattern string) []string {
+	for start := 1; start <= len(pattern); {
+		end := strings.IndexByte(pattern[start:], '/')
+		if end < 0 {
+			moves = append(moves, pattern[start:])
+			break
+		}
+
+		moves = append(moves, pattern[start:start+end])
+		start += end + 1
+	}
+
+	if last := len(moves) - 1; last >= 0 && moves[last] == "" {
+		if rt.SlashStrictly && last > 0 {
+			moves[last] = "/"
+		} else {
+			moves = moves[:last]
+		}
+	}
+
+	return moves
+}
+
+// DefaultPlugOptions sets an basic configuration for the new Plug object.
+func DefaultRouterOptions(rt *RouterConfig) {
+	rt.IndexPath = "/"
+
+	rt.SlashStrictly = false
+
+	rt.RedirectTrailingSlash = false
+
+	rt.RedirectFixedPath = false
+
+	rt.CaseInsensitive = false
+
+	rt.RedirectCanonicalCase = false
+
+	rt.UseEscapedPath = false
+
+	rt.StrictEscaping = false
+
+	rt.RegexpGroups = false
+
+	rt.NotFound = defaultNotFound
+
+	rt.MethodNotAllowed = defaultMethodNotAllowed
+
+	rt.HandleOptions = true
+
+	rt.OptionsHandler = defaultOptions
+
+	rt.HandleHead = true
+
+	rt.ErrorHandler = DefaultErrorHandler
+
+	rt.VersionPrefix = "/v"
+
+	rt.VersionHeader = "Accept-Version"
+
+	rt.VersionVendor = ""
+
+	rt.DefaultVersion = ""
+
+	rt.NotAcceptable = defaultNotAcceptable
+
+	rt.StrictRoutes = false
+}
+
+func defaultMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(405)
+	w.Write([]byte("Method not allowed."))
+}
+
+func defaultOptions(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func defaultNotAcceptable(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+}
+
+func defaultBadRequest(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+}
+
+func defaultNotFound(w http.ResponseWriter, r *http.Request) {
+	http.NotFound(w, r)
+}
+
+// DefaultErrorHandler responds with the code, message and headers of an *HTTPError,
+// or with a 500 status for any other error and for the codes outside 100-599.
+// Nothing is sent if the response has already been written.
+func DefaultErrorHandler(conn Connection, err error) {
+	if conn.Response().Status() != 0 {
+		return
+	}
+
+	var he *HTTPError
+	if !errors.As(err, &he) {
+		he = NewHTTPError(http.StatusInternalServerError, "")
+	}
+
+	header := conn.Response().Header()
+	for key, values := range he.Header {
+		header[key] = values
+	}
+
+	code, message := he.Code, he.Message
+	if code < 100 || code > 599 {
+		code = http.StatusInternalServerError
+		if message == "" {
+			message = http.StatusText(code)
+		}
+	}
+
+	conn.String(code, "%s", message)
+}
@@ -0,0 +1,963 @@
+package plugo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSlashStrictly(t *testing.T) {
+	handler := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}
+	}
+
+	var tests = []struct {
+		name   string
+		strict bool
+		path   string
+		code   int
+		body   string
+	}{
+		{"without slash", false, "/users", http.StatusOK, "users"},
+		{"ignored slash", false, "/users/", http.StatusOK, "users"},
+		{"param ignored slash", false, "/users/1/", http.StatusOK, "user"},
+		{"repeated segment", false, "/users/users", http.StatusOK, "user"},
+		{"strict without slash", true, "/users", http.StatusOK, "users"},
+		{"strict with slash", true, "/users/", http.StatusOK, "users/"},
+		{"strict param", true, "/users/1", http.StatusOK, "user"},
+		{"strict param slash", true, "/users/1/", http.StatusNotFound, "404 page not found\n"},
+		{"strict slash is not a param", true, "/a/", http.StatusNotFound, "404 page not found\n"},
+		{"strict slash is not a regexp", true, "/b/", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		router := New(func(config *RouterConfig) {
+			config.SlashStrictly = test.strict
+		})
+		router.Get("/users", handler("users"))
+		router.Get("/users/:id", handler("user"))
+		router.Get("/a/:x", handler("a"))
+		router.Get("/b/{id:.+}", handler("b"))
+		if test.strict {
+			router.Get("/users/", handler("users/"))
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s got %d '%s' want %d '%s'", test.name, w.Code, w.Body.String(), test.code, test.body)
+		}
+	}
+}
+
+func TestRegexpSegments(t *testing.T) {
+	router := New(func(config *RouterConfig) {
+		config.RegexpGroups = true
+	})
+
+	router.Get("/posts/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := NewConnection(w, r).Param("id")
+		w.Write([]byte("post " + id))
+	})
+	router.Get("/posts/{slug:[a-z-]+}/edit", func(w http.ResponseWriter, r *http.Request) {
+		slug, _ := NewConnection(w, r).Param("slug")
+		w.Write([]byte("edit " + slug))
+	})
+	router.Get("/archive/{date:(?P<year>[0-9]{4})-(?P<month>[0-9]{2})}", func(w http.ResponseWriter, r *http.Request) {
+		conn := NewConnection(w, r)
+		date, _ := conn.Param("date")
+		year, _ := conn.Param("year")
+		month, _ := conn.Param("month")
+		w.Write([]byte(date + " " + year + " " + month))
+	})
+	router.Get("/codes/{[A-Z]{3}}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("code"))
+	})
+
+	var tests = []struct {
+		name string
+		path string
+		code int
+		body string
+	}{
+		{"named value", "/posts/42", http.StatusOK, "post 42"},
+		{"anchored expression", "/posts/42a", http.StatusNotFound, "404 page not found\n"},
+		{"other expression", "/posts/hello-world/edit", http.StatusOK, "edit hello-world"},
+		{"capture groups", "/archive/2023-04", http.StatusOK, "2023-04 2023 04"},
+		{"unnamed expression", "/codes/ABC", http.StatusOK, "code"},
+		{"unnamed anchored", "/codes/ABCD", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s got %d '%s' want %d '%s'", test.name, w.Code, w.Body.String(), test.code, test.body)
+		}
+	}
+}
+
+func TestCatchAll(t *testing.T) {
+	var tests = []struct {
+		name   string
+		strict bool
+		path   string
+		code   int
+		body   string
+	}{
+		{"one level", false, "/files/a.txt", http.StatusOK, "files a.txt"},
+		{"multi level", false, "/files/a/b/c.txt", http.StatusOK, "files a/b/c.txt"},
+		{"trailing slash", false, "/files/a/b/", http.StatusOK, "files a/b/"},
+		{"empty rest", false, "/files", http.StatusOK, "files "},
+		{"cleaned rest", false, "/files/a/../b.txt", http.StatusOK, "files b.txt"},
+		{"static priority", false, "/files/readme", http.StatusOK, "readme"},
+		{"unnamed", false, "/static/css/main.css", http.StatusOK, "static"},
+		{"strict multi level", true, "/files/a/b/c.txt", http.StatusOK, "files a/b/c.txt"},
+		{"strict trailing slash", true, "/files/a/b/", http.StatusOK, "files a/b/"},
+		{"strict empty rest", true, "/files/", http.StatusOK, "files "},
+	}
+
+	for _, test := range tests {
+		router := New(func(config *RouterConfig) {
+			config.SlashStrictly = test.strict
+		})
+		router.Get("/files/*filepath", func(w http.ResponseWriter, r *http.Request) {
+			value, _ := NewConnection(w, r).Param("filepath")
+			w.Write([]byte("files " + value))
+		})
+		router.Get("/files/readme", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("readme"))
+		})
+		router.Get("/static/*", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("static"))
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s got %d '%s' want %d '%s'", test.name, w.Code, w.Body.String(), test.code, test.body)
+		}
+	}
+}
+
+func TestCompositeSegments(t *testing.T) {
+	router := New()
+
+	router.GetC("/files/:name.:ext", func(conn Connection) error {
+		name, _ := conn.Param("name")
+		ext, _ := conn.Param("ext")
+		return conn.String(http.StatusOK, "file %s %s", name, ext)
+	})
+	router.GetC("/files/:name.min.:ext", func(conn Connection) error {
+		name, _ := conn.Param("name")
+		return conn.String(http.StatusOK, "minified %s", name)
+	})
+	router.GetC("/files/index.html", func(conn Connection) error {
+		return conn.String(http.StatusOK, "index")
+	})
+	router.GetC("/files/:name", func(conn Connection) error {
+		name, _ := conn.Param("name")
+		return conn.String(http.StatusOK, "plain %s", name)
+	})
+	router.GetC("/api/v:major<int>.:minor<int>/users", func(conn Connection) error {
+		major, _ := conn.ParamInt("major")
+		minor, _ := conn.ParamInt("minor")
+		return conn.String(http.StatusOK, "api %d %d", major, minor)
+	})
+	router.GetC(`/v1/books\:batchGet`, func(conn Connection) error {
+		return conn.String(http.StatusOK, "batch get")
+	}).Name("books.batchGet")
+	router.GetC(`/urn\:isbn\:123/reviews`, func(conn Connection) error {
+		return conn.String(http.StatusOK, "reviews")
+	})
+	router.GetC(`/urn\:isbn\:123`, func(conn Connection) error {
+		return conn.String(http.StatusOK, "book")
+	})
+	router.GetC(`/items/:id\:publish`, func(conn Connection) error {
+		id, _ := conn.Param("id")
+		return conn.String(http.StatusOK, "publish %s", id)
+	})
+
+	var tests = []struct {
+		name string
+		path string
+		code int
+		body string
+	}{
+		{"name and extension", "/files/report.pdf", http.StatusOK, "file report pdf"},
+		{"last delimiter", "/files/report.final.pdf", http.StatusOK, "file report.final pdf"},
+		{"longer static text first", "/files/app.min.js", http.StatusOK, "minified app"},
+		{"static priority", "/files/index.html", http.StatusOK, "index"},
+		{"param fallback", "/files/README", http.StatusOK, "plain README"},
+		{"static prefix", "/api/v2.1/users", http.StatusOK, "api 2 1"},
+		{"typed parts", "/api/vx.1/users", http.StatusNotFound, "404 page not found\n"},
+		{"escaped colon", "/v1/books:batchGet", http.StatusOK, "batch get"},
+		{"escaped colon is literal", "/v1/books:delete", http.StatusNotFound, "404 page not found\n"},
+		{"escaped colons", "/urn:isbn:123", http.StatusOK, "book"},
+		{"escaped colons in a chain", "/urn:isbn:123/reviews", http.StatusOK, "reviews"},
+		{"escaped colon after a param", "/items/42:publish", http.StatusOK, "publish 42"},
+		{"escaped colon after a param is literal", "/items/42:delete", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s got %d '%s' want %d '%s'", test.name, w.Code, w.Body.String(), test.code, test.body)
+		}
+	}
+
+	if path, err := router.URL("books.batchGet"); err != nil || path != "/v1/books:batchGet" {
+		t.Errorf("got url '%s' %v want '/v1/books:batchGet'", path, err)
+	}
+}
+
+func TestOptionalSegments(t *testing.T) {
+	router := New()
+
+	router.GetC("/posts/:page<int>?", func(conn Connection) error {
+		page, ok := conn.ParamInt("page")
+		return conn.String(http.StatusOK, "posts %d %t", page, ok)
+	}).Name("posts")
+	router.GetC("/archive(/:year(/:month))", func(conn Connection) error {
+		year, _ := conn.Param("year")
+		_, ok := conn.Param("month")
+		return conn.String(http.StatusOK, "archive %s %t", year, ok)
+	}).Name("archive")
+
+	var tests = []struct {
+		name string
+		path string
+		code int
+		body string
+	}{
+		{"absent param", "/posts", http.StatusOK, "posts 0 false"},
+		{"present param", "/posts/2", http.StatusOK, "posts 2 true"},
+		{"invalid param", "/posts/two", http.StatusNotFound, "404 page not found\n"},
+		{"no groups", "/archive", http.StatusOK, "archive  false"},
+		{"outer group", "/archive/2023", http.StatusOK, "archive 2023 false"},
+		{"nested group", "/archive/2023/04", http.StatusOK, "archive 2023 true"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s got %d '%s' want %d '%s'", test.name, w.Code, w.Body.String(), test.code, test.body)
+		}
+	}
+
+	var urls = []struct {
+		name   string
+		values map[string]string
+		want   string
+		err    error
+	}{
+		{"posts", nil, "/posts", nil},
+		{"posts", map[string]string{"page": "3"}, "/posts/3", nil},
+		{"archive", map[string]string{"year": "2023"}, "/archive/2023", nil},
+		{"archive", map[string]string{"year": "2023", "month": "04"}, "/archive/2023/04", nil},
+		{"archive", map[string]string{"year": "2023", "month": ""}, "/archive/2023", nil},
+		{"archive", map[string]string{"month": "04"}, "", ErrMissingParam},
+		{"archive", map[string]string{"year": "2023", "day": "01"}, "", ErrTooManyParams},
+	}
+
+	for _, test := range urls {
+		got, err := router.URLValues(test.name, test.values)
+		if !errors.Is(err, test.err) || got != test.want {
+			t.Errorf("url values %s %v got '%s' (%v) want '%s' (%v)", test.name, test.values, got, err, test.want, test.err)
+		}
+	}
+
+	if _, err := router.URLValues("archive", map[string]string{"month": "04"}); err == nil || !strings.Contains(err.Error(), ":year") {
+		t.Errorf("url values got error %v want the missing year", err)
+	}
+
+	if got, err := router.URL("archive", "2023"); err != nil || got != "/archive/2023" {
+		t.Errorf("url got '%s' (%v) want '/archive/2023'", got, err)
+	}
+
+	t.Run("pattern of the route", func(t *testing.T) {
+		pattern := func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(RouteContext(r.Context()).Pattern))
+		}
+		router.Get("/tags(/:tag)", pattern)
+		router.Mount("/static", http.HandlerFunc(pattern))
+
+		for path, want := range map[string]string{"/tags": "/tags(/:tag)", "/tags/go": "/tags(/:tag)", "/static/logo.png": "/static"} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+			if w.Body.String() != want {
+				t.Errorf("%s got pattern '%s' want '%s'", path, w.Body.String(), want)
+			}
+		}
+	})
+}
+
+func TestRoutePriority(t *testing.T) {
+	type route struct {
+		method  string
+		pattern string
+	}
+
+	var tests = []struct {
+		name    string
+		routes  []route
+		method  string
+		path    string
+		code    int
+		pattern string
+	}{
+		{
+			"static over param",
+			[]route{{"GET", "/users/:id"}, {"GET", "/users/new"}},
+			"GET", "/users/new", http.StatusOK, "/users/new",
+		},
+		{
+			"backtrack from static",
+			[]route{{"GET", "/users/new"}, {"GET", "/users/:id/edit"}},
+			"GET", "/users/new/edit", http.StatusOK, "/users/:id/edit",
+		},
+		{
+			"backtrack from deep static",
+			[]route{{"GET", "/a/b/c"}, {"GET", "/a/:x/d"}},
+			"GET", "/a/b/d", http.StatusOK, "/a/:x/d",
+		},
+		{
+			"regexp over param",
+			[]route{{"GET", "/posts/:slug"}, {"GET", "/posts/{id:[0-9]+}"}},
+			"GET", "/posts/42", http.StatusOK, "/posts/{id:[0-9]+}",
+		},
+		{
+			"backtrack from regexp",
+			[]route{{"GET", "/posts/{id:[0-9]+}"}, {"GET", "/posts/:slug/comments"}},
+			"GET", "/posts/42/comments", http.StatusOK, "/posts/:slug/comments",
+		},
+		{
+			"typed over param",
+			[]route{{"GET", "/items/:name"}, {"GET", "/items/:id<int>"}},
+			"GET", "/items/7", http.StatusOK, "/items/:id<int>",
+		},
+		{
+			"backtrack from typed",
+			[]route{{"GET", "/items/:id<int>"}, {"GET", "/items/:name/tags"}},
+			"GET", "/items/7/tags", http.StatusOK, "/items/:name/tags",
+		},
+		{
+			"param over catch all",
+			[]route{{"GET", "/files/*path"}, {"GET", "/files/:name"}},
+			"GET", "/files/a.txt", http.StatusOK, "/files/:name",
+		},
+		{
+			"backtrack to catch all",
+			[]route{{"GET", "/files/*path"}, {"GET", "/files/:name"}},
+			"GET", "/files/a/b.txt", http.StatusOK, "/files/*path",
+		},
+		{
+			"backtrack to outer catch all",
+			[]route{{"GET", "/*path"}, {"GET", "/api/users/:id"}},
+			"GET", "/api/users/1/posts", http.StatusOK, "/*path",
+		},
+		{
+			"backtrack by method",
+			[]route{{"GET", "/users/new"}, {"POST", "/users/:id"}},
+			"POST", "/users/new", http.StatusOK, "/users/:id",
+		},
+		{
+			"composite over regexp",
+			[]route{{"GET", "/{[a-z.]+}"}, {"GET", "/:name.:ext"}},
+			"GET", "/a.txt", http.StatusOK, "/:name.:ext",
+		},
+		{
+			"no route",
+			[]route{{"GET", "/users/new"}, {"GET", "/users/:id/edit"}},
+			"GET", "/users/new/delete", http.StatusNotFound, "",
+		},
+	}
+
+	for _, test := range tests {
+		var pattern string
+
+		router := New()
+		for _, route := range test.routes {
+			router.Handle(MethodID(route.method), route.pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				pattern = RouteContext(r.Context()).Pattern
+			}))
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+		if w.Code != test.code || pattern != test.pattern {
+			t.Errorf("%s got %d '%s' want %d '%s'", test.name, w.Code, pattern, test.code, test.pattern)
+		}
+	}
+}
+
+func TestRoutePriorityParams(t *testing.T) {
+	router := New()
+	router.GetC("/teams/:team/{id:[0-9]+}", func(conn Connection) error {
+		return conn.String(http.StatusOK, "regexp")
+	})
+	router.GetC("/teams/:team/:user/profile", func(conn Connection) error {
+		return conn.String(http.StatusOK, "%v", conn.PathParams())
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/teams/red/42/profile", nil))
+
+	// the value collected by the regexp segment is dropped when the search backtracks
+	if want := "[red 42]"; w.Body.String() != want {
+		t.Errorf("got params '%s' want '%s'", w.Body.String(), want)
+	}
+}
+
+func TestTryHandle(t *testing.T) {
+	handler := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}
+	}
+
+	var tests = []struct {
+		name    string
+		strict  bool
+		method  MethodID
+		pattern string
+		err     error
+		segment string
+		offset  int
+	}{
+		{"valid route", true, MethodGet, "/users/:id/posts", nil, "", 0},
+		{"invalid method", false, MethodID("GOT"), "/users", ErrMethodNotAllowed, "", -1},
+		{"invalid regexp", false, MethodGet, "/posts/{id:[0-9}", ErrPatternNotCompile, "{id:[0-9}", 7},
+		{"unknown type", false, MethodGet, "/posts/:id<nope>", ErrUnknownParamType, ":id<nope>", 7},
+		{"unbalanced optional", false, MethodGet, "/posts(/:id", ErrUnbalancedOptional, "", -1},
+		{"duplicate route", true, MethodGet, "/users/:id", ErrDuplicateRoute, "", -1},
+		{"replaced route", false, MethodGet, "/users/:id", nil, "", 0},
+		{"other method", true, MethodPost, "/users/:id", nil, "", 0},
+		{"ambiguous param", true, MethodGet, "/users/:name/edit", ErrAmbiguousParam, ":name", 7},
+		{"ambiguous regexp", true, MethodGet, "/codes/{[0-9]+}", ErrAmbiguousParam, "{[0-9]+}", 7},
+		{"allowed ambiguous param", false, MethodGet, "/users/:name/edit", nil, "", 0},
+		{"unreachable route", true, MethodGet, "/files/*path/edit", ErrUnreachableRoute, "*path", 7},
+		{"optional parts", true, MethodGet, "/teams/:team(/:name)", nil, "", 0},
+	}
+
+	for _, test := range tests {
+		router := New(func(config *RouterConfig) {
+			config.StrictRoutes = test.strict
+		})
+		router.Get("/users/:id", handler("user"))
+		router.Get("/codes/{code:[0-9]+}", handler("code"))
+
+		_, err := router.TryHandle(test.method, test.pattern, handler("new"))
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s got error %v want %v", test.name, err, test.err)
+			continue
+		}
+
+		if test.err == nil {
+			continue
+		}
+
+		var routeErr *RouteError
+		if !errors.As(err, &routeErr) || routeErr.Segment != test.segment || routeErr.Offset != test.offset {
+			t.Errorf("%s got route error %+v want segment '%s' at %d", test.name, routeErr, test.segment, test.offset)
+		}
+	}
+
+	t.Run("registers nothing on error", func(t *testing.T) {
+		router := New(func(config *RouterConfig) {
+			config.StrictRoutes = true
+		})
+
+		// the variant without the optional part is valid
+		_, err := router.TryHandle(MethodGet, "/accounts(/:id<nope>)", handler("new"))
+		if !errors.Is(err, ErrUnknownParamType) {
+			t.Fatalf("got error %v want %v", err, ErrUnknownParamType)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/accounts", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("got status %d want %d", w.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("handle panics", func(t *testing.T) {
+		defer func() {
+			var routeErr *RouteError
+			if err, _ := recover().(error); !errors.As(err, &routeErr) || !errors.Is(err, ErrMethodNotAllowed) {
+				t.Errorf("got panic %v want a route error", err)
+			}
+		}()
+
+		New().Handle(MethodID("GOT"), "/", handler("new"))
+	})
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	var tests = []struct {
+		name    string
+		options bool
+		method  string
+		path    string
+		code    int
+		allow   string
+		body    string
+	}{
+		{"allowed methods", true, http.MethodPut, "/users", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS, POST", "GET,HEAD,OPTIONS,POST"},
+		{"param route", true, http.MethodPost, "/users/1", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS, PUT", "GET,HEAD,OPTIONS,PUT"},
+		{"backtracked routes", true, http.MethodDelete, "/users/new", http.StatusMethodNotAllowed, "GET, HEAD, OPTIONS, PUT", "GET,HEAD,OPTIONS,PUT"},
+		{"catch all", true, http.MethodGet, "/files/a/b", http.StatusMethodNotAllowed, "DELETE, OPTIONS", "DELETE,OPTIONS"},
+		{"automatic options", true, http.MethodOptions, "/users", http.StatusNoContent, "GET, HEAD, OPTIONS, POST", ""},
+		{"registered options", true, http.MethodOptions, "/reports", http.StatusOK, "", "reports options"},
+		{"disabled options", false, http.MethodOptions, "/users", http.StatusMethodNotAllowed, "GET, HEAD, POST", "GET,HEAD,POST"},
+		{"not found", true, http.MethodGet, "/teams", http.StatusNotFound, "", "404 page not found\n"},
+	}
+
+	for _, test := range tests {
+		router := New(func(config *RouterConfig) {
+			config.HandleOptions = test.options
+			config.MethodNotAllowed = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				w.Write([]byte(strings.Join(RouteContext(r.Context()).AllowedMethods, ",")))
+			}
+		})
+
+		handler := func(w http.ResponseWriter, r *http.Request) {}
+		router.Get("/users", handler)
+		router.Post("/users", handler)
+		router.Get("/users/:id", handler)
+		router.Get("/users/new", handler)
+		router.Put("/users/:id", handler)
+		router.Delete("/files/*path", handler)
+		router.Get("/reports", handler)
+		router.Options("/reports", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("reports options"))
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+		if w.Code != test.code || w.Header().Get("Allow") != test.allow || w.Body.String() != test.body {
+			t.Errorf("%s got %d '%s' '%s' want %d '%s' '%s'", test.name, w.Code, w.Header().Get("Allow"), w.Body.String(), test.code, test.allow, test.body)
+		}
+	}
+}
+
+func TestHandleHead(t *testing.T) {
+	var tests = []struct {
+		name   string
+		head   bool
+		path   string
+		code   int
+		length string
+		header string
+		body   string
+	}{
+		{"get route", true, "/users", http.StatusOK, "5", "10", ""},
+		{"connection", true, "/posts", http.StatusCreated, "4", "", ""},
+		{"own length", true, "/files", http.StatusOK, "100", "", ""},
+		{"registered head", true, "/reports", http.StatusOK, "", "", "head"},
+		{"failed middleware", true, "/private", http.StatusUnauthorized, "13", "", ""},
+		{"flushing handler", true, "/events", http.StatusOK, "12", "", ""},
+		{"disabled", false, "/users", http.StatusMethodNotAllowed, "", "", "Method not allowed."},
+	}
+
+	for _, test := range tests {
+		router := New(func(config *RouterConfig) {
+			config.HandleHead = test.head
+		})
+
+		router.Get("/users", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Total", "10")
+			w.Write([]byte("users"))
+		})
+		router.GetC("/posts", func(conn Connection) error {
+			return conn.String(http.StatusCreated, "post")
+		})
+		router.Get("/files", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Length", "100")
+		})
+		router.Get("/reports", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("reports"))
+		})
+		router.Head("/reports", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("head"))
+		})
+		router.GetC("/events", func(conn Connection) error {
+			conn.Response().Write([]byte("data: hello\n"))
+			conn.Response().Flush()
+			return nil
+		})
+		router.Get("/private", func(w http.ResponseWriter, r *http.Request) {}, func(fail *error) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				*fail = errors.New("unauthorized")
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+			}
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodHead, test.path, nil))
+
+		if w.Code != test.code || w.Header().Get("Content-Length") != test.length || w.Header().Get("X-Total") != test.header || w.Body.String() != test.body {
+			t.Errorf("%s got %d length '%s' header '%s' body '%s' want %d length '%s' header '%s' body '%s'",
+				test.name, w.Code, w.Header().Get("Content-Length"), w.Header().Get("X-Total"), w.Body.String(),
+				test.code, test.length, test.header, test.body)
+		}
+	}
+}
+
+func TestMethods(t *testing.T) {
+	var calls int
+	counter := func(fail *error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			calls++
+		}
+	}
+
+	router := New()
+	router.RegisterMethod("PROPFIND")
+
+	router.Patch("/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("patch"))
+	})
+	router.Handle("PROPFIND", "/files", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("propfind"))
+	}))
+	router.Any("/any", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("any " + r.Method))
+	})
+	route := router.Match([]MethodID{MethodGet, MethodPost}, "/match", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("match " + r.Method))
+	}, counter).Name("match")
+	router.Get("/reports", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("get reports"))
+	}, func(fail *error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			*fail = errors.New("forbidden")
+			http.Error(w, "forbidden", http.StatusForbidden)
+		}
+	})
+	router.Post("/reports", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("post reports"))
+	})
+
+	var tests = []struct {
+		name   string
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{"patch", http.MethodPatch, "/users/1", http.StatusOK, "patch"},
+		{"extension method", "PROPFIND", "/files", http.StatusOK, "propfind"},
+		{"any standard method", http.MethodDelete, "/any", http.StatusOK, "any DELETE"},
+		{"any extension method", "PROPFIND", "/any", http.StatusOK, "any PROPFIND"},
+		{"any unregistered method", "MKCOL", "/any", http.StatusMethodNotAllowed, "Method not allowed."},
+		{"match get", http.MethodGet, "/match", http.StatusOK, "match GET"},
+		{"match post", http.MethodPost, "/match", http.StatusOK, "match POST"},
+		{"match other method", http.MethodPut, "/match", http.StatusMethodNotAllowed, "Method not allowed."},
+		{"middleware of the method", http.MethodGet, "/reports", http.StatusForbidden, "forbidden\n"},
+		{"middleware of another method", http.MethodPost, "/reports", http.StatusOK, "post reports"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(test.method, test.path, nil))
+
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s got %d '%s' want %d '%s'", test.name, w.Code, w.Body.String(), test.code, test.body)
+		}
+	}
+
+	// two requests served by the matched route
+	if calls != 2 {
+		t.Errorf("got %d middleware calls want 2", calls)
+	}
+
+	if methods := route.Methods(); len(methods) != 2 || route.Method() != MethodGet {
+		t.Errorf("got route methods %v", methods)
+	}
+
+	if _, err := router.TryHandle("MKCOL", "/files", http.NotFoundHandler()); !errors.Is(err, ErrMethodNotAllowed) {
+		t.Errorf("got error %v want %v", err, ErrMethodNotAllowed)
+	}
+
+	for _, name := range []string{"", "BAD METHOD", "GET/1", "MÉTHOD"} {
+		func() {
+			defer func() {
+				if err, _ := recover().(error); !errors.Is(err, ErrInvalidMethod) {
+					t.Errorf("register %q got panic %v want %v", name, err, ErrInvalidMethod)
+				}
+			}()
+
+			router.RegisterMethod(name)
+		}()
+	}
+}
+
+func TestRedirects(t *testing.T) {
+	var tests = []struct {
+		name     string
+		trailing bool
+		fixed    bool
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{"remove trailing slash", true, false, http.MethodGet, "/users/", http.StatusMovedPermanently, "/users"},
+		{"add trailing slash", true, false, http.MethodGet, "/posts", http.StatusMovedPermanently, "/posts/"},
+		{"other methods", true, false, http.MethodPost, "/users/?page=2", http.StatusPermanentRedirect, "/users?page=2"},
+		{"param route", true, false, http.MethodGet, "/files/a.txt/", http.StatusMovedPermanently, "/files/a.txt"},
+		{"no alternate route", true, false, http.MethodGet, "/teams/", http.StatusNotFound, ""},
+		{"trailing slash disabled", false, false, http.MethodGet, "/users/", http.StatusNotFound, ""},
+		{"fixed path", false, true, http.MethodGet, "/files/../users?page=2", http.StatusMovedPermanently, "/users?page=2"},
+		{"repeated slashes", false, true, http.MethodPut, "//users", http.StatusPermanentRedirect, "/users"},
+		{"fixed path and trailing slash", true, true, http.MethodGet, "//posts", http.StatusMovedPermanently, "/posts/"},
+		{"fixed path without route", false, true, http.MethodGet, "//teams", http.StatusNotFound, ""},
+		{"fixed path disabled", false, false, http.MethodGet, "//users", http.StatusOK, ""},
+		{"clean path", true, true, http.MethodGet, "/users", http.StatusOK, ""},
+	}
+
+	for _, test := range tests {
+		router := New(func(config *RouterConfig) {
+			config.SlashStrictly = true
+			config.RedirectTrailingSlash = test.trailing
+			config.RedirectFixedPath = test.fixed
+		})
+
+		handler := func(w http.ResponseWriter, r *http.Request) {}
+		router.Get("/users", handler)
+		router.Post("/users", handler)
+		router.Put("/users", handler)
+		router.Get("/posts/", handler)
+		router.Get("/files/:name", handler)
+
+		r := httptest.NewRequest(test.method, "/", nil)
+		r.URL.Path, r.URL.RawQuery, _ = strings.Cut(test.path, "?")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code || w.Header().Get("Location") != test.location {
+			t.Errorf("%s got %d '%s' want %d '%s'", test.name, w.Code, w.Header().Get("Location"), test.code, test.location)
+		}
+	}
+
+	t.Run("mounted router", func(t *testing.T) {
+		sub := New(func(config *RouterConfig) {
+			config.SlashStrictly = true
+			config.RedirectTrailingSlash = true
+		})
+		sub.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+		router := New()
+		router.Mount("/api", sub)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/users/", nil))
+
+		if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/api/users" {
+			t.Errorf("got %d '%s' want %d '/api/users'", w.Code, w.Header().Get("Location"), http.StatusMovedPermanently)
+		}
+	})
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	var tests = []struct {
+		name     string
+		redirect bool
+		path     string
+		code     int
+		location string
+		body     string
+	}{
+		{"static route", false, "/ABOUT/Team", http.StatusOK, "", "/about/team"},
+		{"param value keeps case", false, "/USERS/Alice/Posts", http.StatusOK, "", "/users/:name/posts Alice"},
+		{"static chain", false, "/Blog/2023/Spring-Sale", http.StatusOK, "", "/blog/2023/Spring-Sale"},
+		{"catch all", false, "/STATIC/CSS/Main.css", http.StatusOK, "", "/static/*file CSS/Main.css"},
+		{"different text", false, "/abouts/team", http.StatusNotFound, "", "404 page not found\n"},
+		{"canonical redirect", true, "/ABOUT/Team?ref=ad", http.StatusMovedPermanently, "/about/team?ref=ad", ""},
+		{"redirect keeps values", true, "/USERS/Alice/Posts/", http.StatusMovedPermanently, "/users/Alice/posts/", ""},
+		{"redirect keeps catch all", true, "/Static/CSS/Main.css", http.StatusMovedPermanently, "/static/CSS/Main.css", ""},
+		{"redirect mixed case pattern", true, "/blog/2023/spring-sale", http.StatusMovedPermanently, "/blog/2023/Spring-Sale", ""},
+		{"canonical path", true, "/users/Alice/posts", http.StatusOK, "", "/users/:name/posts Alice"},
+	}
+
+	for _, test := range tests {
+		router := New(func(config *RouterConfig) {
+			config.CaseInsensitive = true
+			config.RedirectCanonicalCase = test.redirect
+		})
+
+		router.GetC("/about/team", func(conn Connection) error {
+			return conn.String(http.StatusOK, "/about/team")
+		})
+		router.GetC("/users/:name/posts", func(conn Connection) error {
+			name, _ := conn.Param("name")
+			return conn.String(http.StatusOK, "/users/:name/posts %s", name)
+		})
+		router.GetC("/blog/2023/Spring-Sale", func(conn Connection) error {
+			return conn.String(http.StatusOK, "/blog/2023/Spring-Sale")
+		})
+		router.GetC("/static/*file", func(conn Connection) error {
+			file, _ := conn.Param("file")
+			return conn.String(http.StatusOK, "/static/*file %s", file)
+		})
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.URL.Path, r.URL.RawQuery, _ = strings.Cut(test.path, "?")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code || w.Header().Get("Location") != test.location || (test.body != "" && w.Body.String() != test.body) {
+			t.Errorf("%s got %d '%s' '%s' want %d '%s' '%s'", test.name, w.Code, w.Header().Get("Location"), w.Body.String(), test.code, test.location, test.body)
+		}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		router := New()
+		router.Get("/about", func(w http.ResponseWriter, r *http.Request) {})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/About", nil))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("got %d want %d", w.Code, http.StatusNotFound)
+		}
+	})
+}
+
+func TestEscapedPath(t *testing.T) {
+	var tests = []struct {
+		name    string
+		escaped bool
+		path    string
+		code    int
+		body    string
+	}{
+		{"encoded slash in param", true, "/files/a%2Fb.txt", http.StatusOK, "/files/:name a/b.txt"},
+		{"encoded char in static", true, "/caf%C3%A9/menu", http.StatusOK, "/café/menu"},
+		{"encoded slash in static", true, "/caf%C3%A9%2Fmenu", http.StatusNotFound, "404 page not found\n"},
+		{"catch all unescaped", true, "/static/css/a%20b%2Fc.css", http.StatusOK, "/static/*file css/a b/c.css"},
+		{"mount", true, "/repos/a%2Fb/files/x%2Fy", http.StatusOK, "/files/:name a/b x/y"},
+		{"malformed escape", true, "/files/%zz", http.StatusOK, "/files/:name %zz"},
+		{"default splits encoded slash", false, "/files/a%2Fb.txt", http.StatusNotFound, "404 page not found\n"},
+		{"default encoded char in static", false, "/caf%C3%A9/menu", http.StatusOK, "/café/menu"},
+	}
+
+	for _, test := range tests {
+		escaped := func(config *RouterConfig) {
+			config.UseEscapedPath = test.escaped
+		}
+
+		router := New(escaped)
+		router.GetC("/files/:name", func(conn Connection) error {
+			name, _ := conn.Param("name")
+			return conn.String(http.StatusOK, "/files/:name %s", name)
+		})
+		router.GetC("/café/menu", func(conn Connection) error {
+			return conn.String(http.StatusOK, "/café/menu")
+		})
+		router.GetC("/static/*file", func(conn Connection) error {
+			file, _ := conn.Param("file")
+			return conn.String(http.StatusOK, "/static/*file %s", file)
+		})
+
+		sub := New(escaped)
+		sub.GetC("/files/:name", func(conn Connection) error {
+			repo, _ := conn.Param("repo")
+			name, _ := conn.Param("name")
+			return conn.String(http.StatusOK, "/files/:name %s %s", repo, name)
+		})
+		router.Mount("/repos/:repo", sub)
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.URL.RawPath = test.path
+		r.URL.Path, _ = url.PathUnescape(test.path)
+		if r.URL.Path == "" {
+			r.URL.Path = test.path
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code || w.Body.String() != test.body {
+			t.Errorf("%s got %d '%s' want %d '%s'", test.name, w.Code, w.Body.String(), test.code, test.body)
+		}
+	}
+
+	t.Run("strict escaping", func(t *testing.T) {
+		router := New(func(config *RouterConfig) {
+			config.UseEscapedPath = true
+			config.StrictEscaping = true
+		})
+		router.Get("/files/:name", func(w http.ResponseWriter, r *http.Request) {})
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.URL.Path, r.URL.RawPath = "/files/%zz", "/files/%zz"
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("got %d want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("encoded slash is not the strict trailing slash", func(t *testing.T) {
+		router := New(func(config *RouterConfig) {
+			config.UseEscapedPath = true
+			config.SlashStrictly = true
+		})
+		router.GetC("/users/", func(conn Connection) error {
+			return conn.String(http.StatusOK, "/users/")
+		})
+		router.GetC("/users/:id", func(conn Connection) error {
+			id, _ := conn.Param("id")
+			return conn.String(http.StatusOK, "/users/:id %s", id)
+		})
+
+		for path, want := range map[string]string{"/users/": "/users/", "/users/%2F": "/users/:id /"} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+			if w.Body.String() != want {
+				t.Errorf("%s got %d '%s' want '%s'", path, w.Code, w.Body.String(), want)
+			}
+		}
+	})
+
+	t.Run("canonical case keeps escapes", func(t *testing.T) {
+		router := New(func(config *RouterConfig) {
+			config.UseEscapedPath = true
+			config.CaseInsensitive = true
+			config.RedirectCanonicalCase = true
+		})
+		router.Get("/café/:name", func(w http.ResponseWriter, r *http.Request) {})
+
+		r := httptest.NewRequest(http.MethodGet, "/CAF%C3%A9/a%2Fb", nil)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if location := w.Header().Get("Location"); w.Code != http.StatusMovedPermanently || location != "/caf%C3%A9/a%2Fb" {
+			t.Errorf("got %d '%s' want %d '/caf%%C3%%A9/a%%2Fb'", w.Code, location, http.StatusMovedPermanently)
+		}
+	})
+}
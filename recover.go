@@ -0,0 +1,133 @@
+package plugo
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicReporter receives the value recovered from a panic and the stack trace of the request goroutine.
+type PanicReporter func(r *http.Request, err any, stack []byte)
+
+// PanicHandler responds to a request whose handler panicked.
+type PanicHandler func(w http.ResponseWriter, r *http.Request, err any)
+
+// RecoverOption represents a handler for setting RecoverConfig configurable parameters.
+type RecoverOption func(*RecoverConfig)
+
+// RecoverConfig is a set of public fields to configurate the Recover middleware
+type RecoverConfig struct {
+	// reports every recovered panic
+	Reporter PanicReporter
+
+	// handler to respond if the headers were not written before the panic
+	Handler PanicHandler
+
+	// renders an HTML debug page with the stack trace instead of calling Handler
+	Development bool
+}
+
+// Recover creates a middleware that recovers from panics in the wrapped handler,
+// usually a Router, so a panic in any handler or MiddlewareFunc is reported
+// and answered with a 500 status instead of killing the request.
+// Panics with http.ErrAbortHandler are propagated to abort the response.
+//
+//	log.Fatal(http.ListenAndServe(":8080", plugo.Recover()(router)))
+func Recover(opts ...RecoverOption) func(http.Handler) http.Handler {
+	config := &RecoverConfig{}
+	DefaultRecoverOptions(config)
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, ok := w.(*Response)
+			if !ok {
+				res = NewResponse(w)
+			}
+
+			r = r.WithContext(WithRouteContext(r.Context()))
+
+			defer func() {
+				err := recover()
+				if err == nil {
+					return
+				}
+
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
+				stack := debug.Stack()
+				if config.Reporter != nil {
+					config.Reporter(r, err, stack)
+				}
+
+				// the response can not be replaced once the headers are sent
+				if res.Status() != 0 {
+					return
+				}
+
+				if config.Development {
+					renderPanicPage(res, r, err, stack, RouteContext(r.Context()).Pattern)
+					return
+				}
+
+				if config.Handler != nil {
+					config.Handler(res, r, err)
+				}
+			}()
+
+			next.ServeHTTP(res, r)
+		})
+	}
+}
+
+// DefaultRecoverOptions sets a basic configuration for the Recover middleware.
+func DefaultRecoverOptions(config *RecoverConfig) {
+	config.Reporter = defaultPanicReporter
+
+	config.Handler = defaultPanicHandler
+
+	config.Development = false
+}
+
+func defaultPanicReporter(r *http.Request, err any, stack []byte) {
+	log.Printf("plugo: panic serving %s %s: %v\n%s", r.Method, r.URL.Path, err, stack)
+}
+
+func defaultPanicHandler(w http.ResponseWriter, r *http.Request, err any) {
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+var panicPage = template.Must(template.New("panic").Parse(`<!DOCTYPE html>
+<html>
+<head><title>panic: {{.Error}}</title></head>
+<body>
+<h1>panic: {{.Error}}</h1>
+<h2>Route</h2>
+<p><code>{{if .Pattern}}{{.Pattern}}{{else}}no route matched{{end}}</code></p>
+<h2>Request</h2>
+<p><code>{{.Request.Method}} {{.Request.URL}} {{.Request.Proto}}</code></p>
+<table>
+{{range $key, $values := .Request.Header}}{{range $values}}<tr><td>{{$key}}</td><td>{{.}}</td></tr>
+{{end}}{{end}}</table>
+<h2>Stack</h2>
+<pre>{{.Stack}}</pre>
+</body>
+</html>
+`))
+
+func renderPanicPage(w http.ResponseWriter, r *http.Request, err any, stack []byte, pattern string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+
+	panicPage.Execute(w, map[string]any{
+		"Error":   err,
+		"Pattern": pattern,
+		"Request": r,
+		"Stack":   string(stack),
+	})
+}
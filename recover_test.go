@@ -0,0 +1,96 @@
+package plugo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	var reported any
+
+	router := New()
+	router.Get("/panic/:id", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	router.Get("/written", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		panic("boom")
+	})
+	router.Get("/middleware", func(w http.ResponseWriter, r *http.Request) {}, func(fail *error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			panic(errors.New("middleware boom"))
+		}
+	})
+	router.Get("/abort", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	reporter := func(config *RecoverConfig) {
+		config.Reporter = func(r *http.Request, err any, stack []byte) {
+			reported = err
+		}
+	}
+
+	handler := Recover(reporter)(router)
+
+	var tests = []struct {
+		name     string
+		path     string
+		code     int
+		reported any
+	}{
+		{"handler panic", "/panic/1", http.StatusInternalServerError, "boom"},
+		{"headers written", "/written", http.StatusAccepted, "boom"},
+		{"middleware panic", "/middleware", http.StatusInternalServerError, "middleware boom"},
+	}
+
+	for _, test := range tests {
+		reported = nil
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+		if w.Code != test.code {
+			t.Errorf("%s got code %d want %d", test.name, w.Code, test.code)
+		}
+
+		if err, ok := reported.(error); ok {
+			reported = err.Error()
+		}
+
+		if reported != test.reported {
+			t.Errorf("%s got reported %v want %v", test.name, reported, test.reported)
+		}
+	}
+
+	t.Run("abort handler", func(t *testing.T) {
+		defer func() {
+			if err := recover(); err != http.ErrAbortHandler {
+				t.Errorf("got panic %v want %v", err, http.ErrAbortHandler)
+			}
+		}()
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort", nil))
+	})
+
+	t.Run("development page", func(t *testing.T) {
+		dev := Recover(reporter, func(config *RecoverConfig) {
+			config.Development = true
+		})(router)
+
+		w := httptest.NewRecorder()
+		dev.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic/<b>", nil))
+
+		body := w.Body.String()
+		if w.Code != http.StatusInternalServerError || !strings.Contains(body, "/panic/:id") {
+			t.Errorf("debug page does not include the route pattern: %d %s", w.Code, body)
+		}
+
+		if strings.Contains(body, "<b>") {
+			t.Error("debug page does not escape the request data")
+		}
+	})
+}
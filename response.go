@@ -1,61 +1,110 @@
-package plugo
-
-import (
-	"bufio"
-	"net"
-	"net/http"
-)
-
-// Response is a wrapper for http.ResponseWriter
-type Response struct {
-	http.ResponseWriter
-
-	status int
-}
-
-func NewResponse(rw http.ResponseWriter) *Response {
-	return &Response{rw, 0}
-}
-
-func (res *Response) Write(b []byte) (n int, err error) {
-	if res.status == 0 {
-		res.status = http.StatusOK
-	}
-
-	if res.status != http.StatusOK {
-		res.WriteHeader(res.status)
-	}
-
-	n, err = res.ResponseWriter.Write(b)
-
-	return
-}
-
-func (res *Response) WriteHeader(statusCode int) {
-	if statusCode != http.StatusOK {
-		res.status = statusCode
-		res.ResponseWriter.WriteHeader(statusCode)
-	}
-}
-
-func (res *Response) Status() int {
-	return res.status
-}
-
-func (res *Response) Unwrap() http.ResponseWriter {
-	return res.ResponseWriter
-}
-
-// Flush implements the http.Flusher interface to allow an HTTP handler to flush
-// buffered data to the client.
-// See [http.Flusher](https://golang.org/pkg/net/http/#Flusher)
-func (res *Response) Flush() {
-	res.ResponseWriter.(http.Flusher).Flush()
-}
-
-// Hijack implements the http.Hijacker interface to allow an HTTP handler to
-// take over the connection.
-// See [http.Hijacker](https://golang.org/pkg/net/http/#Hijacker)
-func (res *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return res.ResponseWriter.(http.Hijacker).Hijack()
-}
+package plugo
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// Response is a wrapper for http.ResponseWriter
+type Response struct {
+	http.ResponseWriter
+
+	status int
+}
+
+func NewResponse(rw http.ResponseWriter) *Response {
+	return &Response{rw, 0}
+}
+
+func (res *Response) Write(b []byte) (n int, err error) {
+	if res.status == 0 {
+		res.WriteHeader(http.StatusOK)
+	}
+
+	n, err = res.ResponseWriter.Write(b)
+
+	return
+}
+
+// WriteHeader sends the status code only once, ignoring superfluous calls.
+func (res *Response) WriteHeader(statusCode int) {
+	if res.status != 0 {
+		return
+	}
+
+	res.status = statusCode
+	res.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Status returns the status code sent to the client, 0 if nothing has been written yet.
+func (res *Response) Status() int {
+	return res.status
+}
+
+func (res *Response) Unwrap() http.ResponseWriter {
+	return res.ResponseWriter
+}
+
+// Flush implements the http.Flusher interface to allow an HTTP handler to flush
+// buffered data to the client.
+// See [http.Flusher](https://golang.org/pkg/net/http/#Flusher)
+func (res *Response) Flush() {
+	res.ResponseWriter.(http.Flusher).Flush()
+}
+
+// Hijack implements the http.Hijacker interface to allow an HTTP handler to
+// take over the connection.
+// See [http.Hijacker](https://golang.org/pkg/net/http/#Hijacker)
+func (res *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return res.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// headResponse serves a HEAD request with the handler of a GET route,
+// discarding the body and sending its length as the Content-Length header.
+type headResponse struct {
+	http.ResponseWriter
+
+	// status code set by the handler and length of the discarded body
+	status int
+	length int
+}
+
+func (res *headResponse) WriteHeader(statusCode int) {
+	if res.status == 0 {
+		res.status = statusCode
+	}
+}
+
+func (res *headResponse) Write(b []byte) (int, error) {
+	if res.status == 0 {
+		res.status = http.StatusOK
+	}
+
+	res.length += len(b)
+
+	return len(b), nil
+}
+
+// Flush does nothing, as the headers are sent by finish and there is no body.
+func (res *headResponse) Flush() {}
+
+func (res *headResponse) Unwrap() http.ResponseWriter {
+	return res.ResponseWriter
+}
+
+// finish sends the headers once the handler has returned, as the length of
+// the body is only known then.
+func (res *headResponse) finish() {
+	if res.status == 0 {
+		res.status = http.StatusOK
+	}
+
+	header := res.Header()
+	if header.Get("Content-Length") == "" && res.status >= 200 && res.status != http.StatusNoContent && res.status != http.StatusNotModified {
+		header.Set("Content-Length", strconv.Itoa(res.length))
+	}
+
+	res.ResponseWriter.WriteHeader(res.status)
+}
@@ -0,0 +1,290 @@
+package plugo
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Route represents an endpoint registered in a Router.
+type Route struct {
+	router  *Router
+	methods []MethodID
+	pattern string
+	name    string
+
+	// patterns without optional parts to build the URL, from the longest to
+	// the shortest, with the prefix of the version for the routes of a version
+	variants []string
+
+	// endpoints registered for every variant
+	endpoints []*endpoint
+
+	// values attached with Meta, shared by the endpoints
+	metadata map[string]any
+}
+
+// Name identifies the route with a unique name to generate its URL with Router.URL.
+func (r *Route) Name(name string) *Route {
+	if _, ok := r.router.routeNames[name]; ok {
+		panic(fmt.Errorf("%w: %s", ErrDuplicateRouteName, name))
+	}
+
+	if r.name != "" {
+		delete(r.router.routeNames, r.name)
+	}
+
+	r.name = name
+	for _, endp := range r.endpoints {
+		endp.name = name
+	}
+
+	r.router.routeNames[name] = r
+
+	return r
+}
+
+// GetName returns the name of the route, empty if it has not been named.
+func (r *Route) GetName() string {
+	return r.name
+}
+
+// Meta attaches a value to the route, available from the RoutingContext of the matched requests.
+func (r *Route) Meta(key string, value any) *Route {
+	if r.metadata == nil {
+		r.metadata = make(map[string]any)
+		for _, endp := range r.endpoints {
+			endp.metadata = r.metadata
+		}
+	}
+
+	r.metadata[key] = value
+
+	return r
+}
+
+// Metadata returns the values attached to the route with Meta.
+func (r *Route) Metadata() map[string]any {
+	return r.metadata
+}
+
+// Method returns the http method of the route, the first one if it was
+// registered for several methods.
+func (r *Route) Method() MethodID {
+	return r.methods[0]
+}
+
+// Methods returns the http methods of the route.
+func (r *Route) Methods() []MethodID {
+	return append([]MethodID(nil), r.methods...)
+}
+
+// Pattern returns the full pattern of the route, including the index path, group prefixes and optional parts.
+func (r *Route) Pattern() string {
+	return r.pattern
+}
+
+// URL builds the path of the named route filling its parametric, regexp and
+// catch all segments, in order, with the given values. Optional parts are
+// skipped when there are not values for them.
+func (rt *Router) URL(name string, params ...string) (string, error) {
+	route, ok := rt.routeNames[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrRouteNameNotFound, name)
+	}
+
+	for _, variant := range route.variants {
+		if len(parseParamKeysFromPattern(cleanPath(variant))) == len(params) {
+			return rt.buildPositionalPath(name, variant, params)
+		}
+	}
+
+	// the longest variant reports the missing or remaining values
+	return rt.buildPositionalPath(name, route.variants[0], params)
+}
+
+// URLValues builds the path of the named route filling its parametric segments
+// with the values of the map. Optional parts are skipped when the map does not
+// have values for them. A value for an optional part given without the values
+// of its enclosing parts reports the missing parameter, and a value for a key
+// that the route does not have reports ErrTooManyParams.
+func (rt *Router) URLValues(name string, values map[string]string) (string, error) {
+	route, ok := rt.routeNames[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrRouteNameNotFound, name)
+	}
+
+	// the first missing parameter, of the longest variant
+	var missing error
+
+	for _, variant := range route.variants {
+		var used []string
+		path, err := buildPath(variant, rt.paramTypes, func(key string) (string, bool) {
+			if key == "" {
+				return "", false
+			}
+
+			value, ok := values[key]
+			used = append(used, key)
+			return value, ok
+		})
+
+		if errors.Is(err, ErrMissingParam) {
+			if missing == nil {
+				missing = err
+			}
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		// the shorter variants do not use the remaining values either
+		keys := parseParamKeysFromPattern(cleanPath(route.variants[0]))
+
+		var unused []string
+		for key, value := range values {
+			if value == "" || containsString(used, key) {
+				continue
+			}
+
+			// a value of an optional part without the values of its enclosing parts
+			if missing != nil && containsString(keys, key) {
+				return "", missing
+			}
+
+			unused = append(unused, key)
+		}
+
+		if len(unused) > 0 {
+			sort.Strings(unused)
+			return "", fmt.Errorf("%w: route %s has no %s", ErrTooManyParams, name, strings.Join(unused, ", "))
+		}
+
+		return path, nil
+	}
+
+	return "", missing
+}
+
+// buildPositionalPath builds the path of pattern filling its dynamic segments
+// with params in order.
+func (rt *Router) buildPositionalPath(name, pattern string, params []string) (string, error) {
+	next := 0
+	path, err := buildPath(pattern, rt.paramTypes, func(key string) (string, bool) {
+		if next >= len(params) {
+			return "", false
+		}
+
+		next++
+		return params[next-1], true
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if next < len(params) {
+		return "", fmt.Errorf("%w: route %s expects %d values, got %d", ErrTooManyParams, name, next, len(params))
+	}
+
+	return path, nil
+}
+
+// buildPath replaces every dynamic segment of pattern with the escaped value
+// returned by lookup, which receives the parameter key or an empty string for
+// unnamed segments. Values of parameters with a type constraint are validated with types.
+func buildPath(pattern string, types map[string]ParamType, lookup func(key string) (string, bool)) (string, error) {
+	segments := strings.Split(cleanPath(pattern), "/")
+
+	for i, segment := range segments {
+		kind := parseStringToNodeType(segment)
+		if segment == "" || kind == nodeStatic {
+			segments[i] = unescapeStatic(segment)
+			continue
+		}
+
+		if kind == nodeComposite {
+			var built strings.Builder
+
+			for _, part := range parseCompositeSegment(segment) {
+				if part.name == "" {
+					built.WriteString(part.static)
+					continue
+				}
+
+				value, err := paramValue(pattern, segment, part.name, part.typ, types, lookup)
+				if err != nil {
+					return "", err
+				}
+
+				built.WriteString(value)
+			}
+
+			segments[i] = built.String()
+			continue
+		}
+
+		if kind == nodeParam {
+			name, typ := splitParamSegment(segment)
+
+			value, err := paramValue(pattern, segment, name, typ, types, lookup)
+			if err != nil {
+				return "", err
+			}
+
+			segments[i] = value
+			continue
+		}
+
+		value, ok := lookup(segmentKey(segment))
+		if !ok || value == "" {
+			return "", fmt.Errorf("%w: %s in %s", ErrMissingParam, segment, pattern)
+		}
+
+		switch kind {
+		case nodeRegexp:
+			rex, err := compileSegmentRegexp(segment)
+			if err != nil {
+				return "", fmt.Errorf("%w: %s", ErrPatternNotCompile, segment)
+			}
+
+			if !rex.MatchString(value) {
+				return "", fmt.Errorf("%w: %q does not match %s", ErrInvalidParam, value, segment)
+			}
+
+			value = url.PathEscape(value)
+
+		case nodeCatchAll:
+			parts := strings.Split(value, "/")
+			for j := range parts {
+				parts[j] = url.PathEscape(parts[j])
+			}
+
+			value = strings.Join(parts, "/")
+		}
+
+		segments[i] = value
+	}
+
+	return strings.Join(segments, "/"), nil
+}
+
+// paramValue returns the escaped value of the parameter key of a segment,
+// validated with its type constraint.
+func paramValue(pattern, segment, key, typ string, types map[string]ParamType, lookup func(key string) (string, bool)) (string, error) {
+	value, ok := lookup(key)
+	if !ok || value == "" {
+		return "", fmt.Errorf("%w: %s in %s", ErrMissingParam, segment, pattern)
+	}
+
+	if typ != "" {
+		if _, ok := types[typ](value); !ok {
+			return "", fmt.Errorf("%w: %q is not a valid %s", ErrInvalidParam, value, typ)
+		}
+	}
+
+	return url.PathEscape(value), nil
+}
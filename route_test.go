@@ -0,0 +1,94 @@
+package plugo
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRouterURL(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	router := New()
+	router.Get("/", noop).Name("home")
+	router.Get("/users/:id", noop).Name("user.show")
+	router.Get("/users/:id/posts/:post/", noop).Name("user.post")
+	router.Group("/archive").Get("/{[0-9]+}", noop).Name("archive")
+	router.Get("/static/*", noop).Name("static")
+
+	var tests = []struct {
+		name   string
+		route  string
+		params []string
+		want   string
+		err    error
+	}{
+		{"static route", "home", nil, "/", nil},
+		{"one param", "user.show", []string{"42"}, "/users/42", nil},
+		{"escaped param", "user.show", []string{"a b/c"}, "/users/a%20b%2Fc", nil},
+		{"trailing slash", "user.post", []string{"1", "2"}, "/users/1/posts/2/", nil},
+		{"group regexp", "archive", []string{"2023"}, "/archive/2023", nil},
+		{"catch all", "static", []string{"css/main.css"}, "/static/css/main.css", nil},
+		{"invalid regexp value", "archive", []string{"last"}, "", ErrInvalidParam},
+		{"missing param", "user.post", []string{"1"}, "", ErrMissingParam},
+		{"too many params", "user.show", []string{"1", "2"}, "", ErrTooManyParams},
+		{"unknown route", "nope", nil, "", ErrRouteNameNotFound},
+	}
+
+	for _, test := range tests {
+		got, err := router.URL(test.route, test.params...)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s got error %v want %v", test.name, err, test.err)
+		}
+
+		if got != test.want {
+			t.Errorf("%s got '%s' want '%s'", test.name, got, test.want)
+		}
+	}
+
+	got, err := router.URLValues("user.post", map[string]string{"id": "7", "post": "hello world"})
+	if err != nil || got != "/users/7/posts/hello%20world/" {
+		t.Errorf("url values got '%s' (%v) want '/users/7/posts/hello%%20world/'", got, err)
+	}
+
+	router.Get("/posts/{id:[0-9]+}", noop).Name("post")
+
+	got, err = router.URLValues("post", map[string]string{"id": "12"})
+	if err != nil || got != "/posts/12" {
+		t.Errorf("url values got '%s' (%v) want '/posts/12'", got, err)
+	}
+
+	router.Get("/api/v:major<int>/:name.:ext", noop).Name("download")
+
+	got, err = router.URL("download", "2", "report", "pdf")
+	if err != nil || got != "/api/v2/report.pdf" {
+		t.Errorf("url got '%s' (%v) want '/api/v2/report.pdf'", got, err)
+	}
+
+	_, err = router.URL("download", "two", "report", "pdf")
+	if !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("url got error %v want %v", err, ErrInvalidParam)
+	}
+
+	router.Get("/files/*filepath", noop).Name("files")
+
+	got, err = router.URLValues("files", map[string]string{"filepath": "docs/a b.txt"})
+	if err != nil || got != "/files/docs/a%20b.txt" {
+		t.Errorf("url values got '%s' (%v) want '/files/docs/a%%20b.txt'", got, err)
+	}
+
+	_, err = router.URLValues("post", map[string]string{"id": "x"})
+	if !errors.Is(err, ErrInvalidParam) {
+		t.Errorf("url values got error %v want %v", err, ErrInvalidParam)
+	}
+
+	_, err = router.URLValues("user.post", map[string]string{"id": "7"})
+	if !errors.Is(err, ErrMissingParam) {
+		t.Errorf("url values got error %v want %v", err, ErrMissingParam)
+	}
+
+	_, err = router.URLValues("post", map[string]string{"id": "12", "slug": "hello"})
+	if !errors.Is(err, ErrTooManyParams) {
+		t.Errorf("url values got error %v want %v", err, ErrTooManyParams)
+	}
+}
@@ -1,60 +1,455 @@
-package plugo
-
-import (
-	"path"
-	"regexp"
-	"strings"
-)
-
-func cleanPath(p string) string {
-	if p == "" {
-		return "/"
-	}
-
-	if p[0] != '/' {
-		p = "/" + p
-	}
-
-	np := path.Clean(p)
-	// path.Clean removes trailing slash except for root;
-	// put the trailing slash back if necessary.
-	if p[len(p)-1] == '/' && np != "/" {
-		np += "/"
-	}
-
-	return np
-}
-
-func parseStringToNodeType(s string) nodeType {
-	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
-		return nodeRegexp
-	}
-
-	if strings.HasPrefix(s, ":") {
-		return nodeParam
-	}
-
-	if strings.HasSuffix(s, "*") {
-		return nodeCatchAll
-	}
-
-	return nodeStatic
-}
-
-func parseParamKeysFromPattern(pattern string) []string {
-	paramsMatcher := regexp.MustCompile(`(:[a-zA-Z0-9])\w+`)
-	moves := strings.Split(pattern, "/")
-	if strings.HasSuffix(pattern, "/") {
-		moves = moves[1:]
-	}
-
-	maxLen := len(moves)
-
-	// Removing ":" from keys
-	result := paramsMatcher.FindAllString(pattern, maxLen)
-	for i := range result {
-		result[i] = strings.Replace(result[i], ":", "", 1)
-	}
-
-	return result
-}
+package plugo
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	if p[0] != '/' {
+		p = "/" + p
+	}
+
+	np := path.Clean(p)
+	// path.Clean removes trailing slash except for root;
+	// put the trailing slash back if necessary.
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+
+	return np
+}
+
+// joinPaths appends p to base avoiding a duplicated slash between them.
+func joinPaths(base, p string) string {
+	if p == "" {
+		return base
+	}
+
+	if p[0] != '/' {
+		p = "/" + p
+	}
+
+	return strings.TrimSuffix(base, "/") + p
+}
+
+func parseStringToNodeType(s string) nodeType {
+	if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+		return nodeRegexp
+	}
+
+	if strings.IndexByte(s, ':') >= 0 && len(parseCompositeSegment(s)) > 1 {
+		return nodeComposite
+	}
+
+	if strings.HasPrefix(s, ":") {
+		return nodeParam
+	}
+
+	if strings.HasPrefix(s, "*") || strings.HasSuffix(s, "*") {
+		return nodeCatchAll
+	}
+
+	return nodeStatic
+}
+
+// splitRegexpSegment returns the name and the expression of a regexp segment
+// like {name:[0-9]+}. The name is empty for unnamed segments like {[0-9]+}.
+func splitRegexpSegment(segment string) (name, expr string) {
+	expr = segment[1 : len(segment)-1]
+
+	i := strings.IndexByte(expr, ':')
+	if i > 0 && isParamName(expr[:i]) {
+		return expr[:i], expr[i+1:]
+	}
+
+	return "", expr
+}
+
+// compileSegmentRegexp compiles the expression of a regexp segment anchored to the whole segment.
+func compileSegmentRegexp(segment string) (*regexp.Regexp, error) {
+	_, expr := splitRegexpSegment(segment)
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+// isParamName reports whether s is a valid parameter name.
+func isParamName(s string) bool {
+	for _, c := range s {
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+// splitParamSegment returns the name and the type constraint of a parametric
+// segment like :id<int>. The type is empty for segments without constraint.
+func splitParamSegment(segment string) (name, typ string) {
+	name = segment[1:]
+
+	i := strings.IndexByte(name, '<')
+	if i >= 0 && strings.HasSuffix(name, ">") {
+		return name[:i], name[i+1 : len(name)-1]
+	}
+
+	return name, ""
+}
+
+// segmentKey returns the key of the value matched by a dynamic segment, empty for unnamed segments.
+func segmentKey(segment string) string {
+	switch parseStringToNodeType(segment) {
+	case nodeParam:
+		name, _ := splitParamSegment(segment)
+		return name
+
+	case nodeRegexp:
+		name, _ := splitRegexpSegment(segment)
+		return name
+
+	case nodeCatchAll:
+		if strings.HasPrefix(segment, "*") {
+			return segment[1:]
+		}
+	}
+
+	return ""
+}
+
+// parseParamKeysFromPattern returns the keys of the parametric, regexp and catch
+// all segments of a pattern, with an empty key for unnamed segments.
+func parseParamKeysFromPattern(pattern string) []string {
+	result := make([]string, 0)
+
+	for _, segment := range strings.Split(pattern, "/") {
+		switch parseStringToNodeType(segment) {
+		case nodeParam, nodeRegexp, nodeCatchAll:
+			result = append(result, segmentKey(segment))
+
+		case nodeComposite:
+			for _, part := range parseCompositeSegment(segment) {
+				if part.name != "" {
+					result = append(result, part.name)
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// parseRegexpGroups returns, for every parametric, regexp and catch all segment,
+// the compiled regexp if it has named capture groups or nil otherwise.
+func parseRegexpGroups(segments []string) []*regexp.Regexp {
+	var result []*regexp.Regexp
+	var found bool
+
+	for _, segment := range segments {
+		switch parseStringToNodeType(segment) {
+		case nodeParam, nodeCatchAll:
+			result = append(result, nil)
+
+		case nodeComposite:
+			for _, part := range parseCompositeSegment(segment) {
+				if part.name != "" {
+					result = append(result, nil)
+				}
+			}
+
+		case nodeRegexp:
+			rex, err := compileSegmentRegexp(segment)
+			if err != nil || !hasNamedGroups(rex) {
+				result = append(result, nil)
+				continue
+			}
+
+			result = append(result, rex)
+			found = true
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	return result
+}
+
+func hasNamedGroups(rex *regexp.Regexp) bool {
+	for _, name := range rex.SubexpNames() {
+		if name != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// segmentPart is a static text or a parameter of a composite segment.
+type segmentPart struct {
+	// static text, empty for parameters
+	static string
+
+	// name of the parameter, empty for static text
+	name string
+
+	// type constraint of the parameter
+	typ string
+}
+
+// parseCompositeSegment splits a segment like :name.:ext or v:major<int> in
+// static text and parameters. An escaped colon, like books\:batchGet, is static text.
+func parseCompositeSegment(segment string) []segmentPart {
+	var parts []segmentPart
+	var static strings.Builder
+
+	for i := 0; i < len(segment); {
+		if strings.HasPrefix(segment[i:], `\:`) {
+			static.WriteByte(':')
+			i += 2
+			continue
+		}
+
+		if segment[i] != ':' || i+1 >= len(segment) || !isParamStart(segment[i+1]) {
+			static.WriteByte(segment[i])
+			i++
+			continue
+		}
+
+		if static.Len() > 0 {
+			parts = append(parts, segmentPart{static: static.String()})
+			static.Reset()
+		}
+
+		j := i + 1
+		for j < len(segment) && isParamName(segment[j:j+1]) {
+			j++
+		}
+
+		part := segmentPart{name: segment[i+1 : j]}
+		if j < len(segment) && segment[j] == '<' {
+			if k := strings.IndexByte(segment[j:], '>'); k > 0 {
+				part.typ = segment[j+1 : j+k]
+				j += k + 1
+			}
+		}
+
+		parts = append(parts, part)
+		i = j
+	}
+
+	if static.Len() > 0 {
+		parts = append(parts, segmentPart{static: static.String()})
+	}
+
+	return parts
+}
+
+// unescapeStatic returns a static segment with its escaped colons unescaped.
+func unescapeStatic(segment string) string {
+	return strings.ReplaceAll(segment, `\:`, ":")
+}
+
+// unescapeStatics returns a copy of static segments with their escaped colons unescaped.
+func unescapeStatics(segments []string) []string {
+	unescaped := make([]string, len(segments))
+	for i, segment := range segments {
+		unescaped[i] = unescapeStatic(segment)
+	}
+
+	return unescaped
+}
+
+// compileCompositeSegment compiles the parts of a composite segment to a
+// regexp with a group for every parameter.
+func compileCompositeSegment(parts []segmentPart) (*regexp.Regexp, error) {
+	var expr strings.Builder
+
+	expr.WriteString("^")
+	for _, part := range parts {
+		if part.name == "" {
+			expr.WriteString(regexp.QuoteMeta(part.static))
+		} else {
+			expr.WriteString("(.+)")
+		}
+	}
+	expr.WriteString("$")
+
+	return regexp.Compile(expr.String())
+}
+
+// compositeNodeLabel returns the label of the node of a composite segment,
+// without the names of its parameters.
+func compositeNodeLabel(parts []segmentPart) string {
+	var label strings.Builder
+
+	for _, part := range parts {
+		switch {
+		case part.name == "":
+			// the colons of the text are escaped to tell them from the parameters
+			label.WriteString(strings.ReplaceAll(part.static, ":", `\:`))
+		case part.typ != "":
+			label.WriteString(":<" + part.typ + ">")
+		default:
+			label.WriteString(":")
+		}
+	}
+
+	return label.String()
+}
+
+// compositeStaticLen returns the length of the static text of a composite node label.
+func compositeStaticLen(label string) int {
+	n := 0
+	for i := 0; i < len(label); i++ {
+		if label[i] == '\\' {
+			n++
+			i++
+			continue
+		}
+
+		if label[i] != ':' {
+			n++
+			continue
+		}
+
+		// skips the type constraint of the parameter
+		if i+1 < len(label) && label[i+1] == '<' {
+			if k := strings.IndexByte(label[i:], '>'); k > 0 {
+				i += k
+			}
+		}
+	}
+
+	return n
+}
+
+// equalFoldASCII reports whether s and t are equal ignoring the case of ASCII letters.
+func equalFoldASCII(s, t string) bool {
+	if len(s) != len(t) {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if lowerASCII(s[i]) != lowerASCII(t[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+
+	return c
+}
+
+// containsString reports whether s is in list.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isParamStart(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// expandOptionalPattern returns the patterns described by a pattern with
+// optional parts, from the longest to the shortest. Optional parts are
+// enclosed in parentheses, like /archive(/:year(/:month)), and a parametric
+// segment ending in '?', like /posts/:page?, is a shortcut for /posts(/:page).
+func expandOptionalPattern(pattern string) ([]string, error) {
+	var converted strings.Builder
+	for i, segment := range strings.Split(pattern, "/") {
+		switch {
+		case i == 0:
+			converted.WriteString(segment)
+
+		case len(segment) > 2 && segment[0] == ':' && strings.HasSuffix(segment, "?"):
+			converted.WriteString("(/" + segment[:len(segment)-1] + ")")
+
+		default:
+			converted.WriteString("/" + segment)
+		}
+	}
+
+	pattern = converted.String()
+
+	variants, rest, err := expandOptionalGroup(pattern, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if rest != len(pattern) {
+		return nil, fmt.Errorf("%w: unexpected ')' at %d in %s", ErrUnbalancedOptional, rest, pattern)
+	}
+
+	return variants, nil
+}
+
+// expandOptionalGroup expands pattern from start until the end of the current
+// group, returning the variants and the index after the group.
+func expandOptionalGroup(pattern string, start int) ([]string, int, error) {
+	variants := []string{""}
+	braces := 0
+
+	i := start
+	for i < len(pattern) {
+		c := pattern[i]
+
+		switch {
+		case c == '{':
+			braces++
+
+		case c == '}' && braces > 0:
+			braces--
+
+		case c == '(' && braces == 0:
+			group, next, err := expandOptionalGroup(pattern, i+1)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			if next >= len(pattern) || pattern[next] != ')' {
+				return nil, 0, fmt.Errorf("%w: missing ')' for '(' at %d in %s", ErrUnbalancedOptional, i, pattern)
+			}
+
+			// every variant with the group, then without it
+			expanded := make([]string, 0, len(variants)*(len(group)+1))
+			for _, variant := range variants {
+				for _, g := range group {
+					expanded = append(expanded, variant+g)
+				}
+			}
+
+			variants = append(expanded, variants...)
+			i = next + 1
+			continue
+
+		case c == ')' && braces == 0:
+			return variants, i, nil
+		}
+
+		for j := range variants {
+			variants[j] += pattern[i : i+1]
+		}
+
+		i++
+	}
+
+	return variants, i, nil
+}
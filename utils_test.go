@@ -1,49 +1,116 @@
-package plugo
-
-import "testing"
-
-func TestCleanPath(t *testing.T) {
-	var tests = []struct {
-		name string
-		path string
-		want string
-	}{
-		{"check empty root", "", "/"},
-		{"adding slash at the beginning", "foo/bar", "/foo/bar"},
-		{"trailing slash back", "/hello/plugo/", "/hello/plugo/"},
-	}
-
-	for _, test := range tests {
-		got := cleanPath(test.path)
-		if got != test.want {
-			t.Errorf("%s got '%s' want '%s'", test.name, got, test.want)
-		}
-	}
-}
-
-func TestParseParamKeysFromPattern(t *testing.T) {
-	var tests = []struct {
-		name    string
-		pattern string
-		want    []string
-	}{
-		{"one param", "/hello/:world", []string{"world"}},
-		{"two params sequentially", "/users/:id/:name", []string{"id", "name"}},
-		{"more params", "/friends/:id/photos/:folder/:name", []string{"id", "folder", "name"}},
-	}
-
-	for _, test := range tests {
-		got := parseParamKeysFromPattern(test.pattern)
-
-		if len(got) != len(test.want) {
-			t.Errorf("%s got %v want %v", test.name, got, test.want)
-		}
-
-		for i := range got {
-			key := got[i]
-			if test.want[i] != key {
-				t.Errorf("%s got key %s want key %s", test.name, key, test.want[i])
-			}
-		}
-	}
-}
+package plugo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	var tests = []struct {
+		name string
+		path string
+		want string
+	}{
+		{"check empty root", "", "/"},
+		{"adding slash at the beginning", "foo/bar", "/foo/bar"},
+		{"trailing slash back", "/hello/plugo/", "/hello/plugo/"},
+	}
+
+	for _, test := range tests {
+		got := cleanPath(test.path)
+		if got != test.want {
+			t.Errorf("%s got '%s' want '%s'", test.name, got, test.want)
+		}
+	}
+}
+
+func TestParseParamKeysFromPattern(t *testing.T) {
+	var tests = []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{"one param", "/hello/:world", []string{"world"}},
+		{"two params sequentially", "/users/:id/:name", []string{"id", "name"}},
+		{"more params", "/friends/:id/photos/:folder/:name", []string{"id", "folder", "name"}},
+		{"single character names", "/points/:x/:y", []string{"x", "y"}},
+		{"unnamed regexp segments", "/codes/{(?:a|b)}/:code", []string{"", "code"}},
+		{"named regexp segments", "/codes/{id:[0-9]+}/:code", []string{"id", "code"}},
+	}
+
+	for _, test := range tests {
+		got := parseParamKeysFromPattern(test.pattern)
+
+		if len(got) != len(test.want) {
+			t.Errorf("%s got %v want %v", test.name, got, test.want)
+		}
+
+		for i := range got {
+			key := got[i]
+			if test.want[i] != key {
+				t.Errorf("%s got key %s want key %s", test.name, key, test.want[i])
+			}
+		}
+	}
+}
+
+func TestParseStringToNodeType(t *testing.T) {
+	var tests = []struct {
+		segment string
+		want    nodeType
+	}{
+		{"users", nodeStatic},
+		{"12:30", nodeStatic},
+		{":id", nodeParam},
+		{":id<int>", nodeParam},
+		{"{[0-9]+}", nodeRegexp},
+		{"{id:[0-9]+}", nodeRegexp},
+		{"*", nodeCatchAll},
+		{"*filepath", nodeCatchAll},
+		{":name.:ext", nodeComposite},
+		{"v:major", nodeComposite},
+		{":major<int>.:minor<int>", nodeComposite},
+	}
+
+	for _, test := range tests {
+		got := parseStringToNodeType(test.segment)
+		if got != test.want {
+			t.Errorf("%s got type %d want %d", test.segment, got, test.want)
+		}
+	}
+}
+
+func TestExpandOptionalPattern(t *testing.T) {
+	var tests = []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{"without optional parts", "/users/:id", []string{"/users/:id"}},
+		{"optional param", "/posts/:page?", []string{"/posts/:page", "/posts"}},
+		{"optional typed param", "/posts/:page<int>?/comments", []string{"/posts/:page<int>/comments", "/posts/comments"}},
+		{"nested groups", "/archive(/:year(/:month))", []string{"/archive/:year/:month", "/archive/:year", "/archive"}},
+		{"sequential groups", "/a(/b)(/c)", []string{"/a/b/c", "/a/c", "/a/b", "/a"}},
+		{"regexp parentheses", "/codes/{(?:a|b)}(/:n)", []string{"/codes/{(?:a|b)}/:n", "/codes/{(?:a|b)}"}},
+		{"multibyte characters", "/café(/ñandú)", []string{"/café/ñandú", "/café"}},
+	}
+
+	for _, test := range tests {
+		got, err := expandOptionalPattern(test.pattern)
+		if err != nil {
+			t.Errorf("%s got error %v", test.name, err)
+			continue
+		}
+
+		if strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("%s got %v want %v", test.name, got, test.want)
+		}
+	}
+
+	for _, pattern := range []string{"/archive(/:year", "/archive/:year)"} {
+		if _, err := expandOptionalPattern(pattern); !errors.Is(err, ErrUnbalancedOptional) {
+			t.Errorf("%s got error %v want %v", pattern, err, ErrUnbalancedOptional)
+		}
+	}
+}
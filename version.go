@@ -0,0 +1,178 @@
+package plugo
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VersionOption represents a handler for setting VersionConfig configurable parameters.
+type VersionOption func(*VersionConfig)
+
+// VersionConfig is a set of public fields to configurate an API version.
+type VersionConfig struct {
+	// sends the Deprecation header in the responses of the version
+	Deprecated bool
+
+	// date sent in the Sunset header of the responses of the version, when it
+	// stops being served, not sent if it is zero
+	Sunset time.Time
+}
+
+// apiVersion is the version of the routes registered through a group created with Version.
+type apiVersion struct {
+	name string
+
+	// prefix of the routes of the version selected by the path, like /api/v2
+	prefix string
+
+	// accepts the requests for the version
+	matcher Matcher
+}
+
+// Version creates a new group for the routes of an API version, like 2 or v2.
+// Every route registered through it is served to the requests for its path
+// that request the version, with the VersionHeader or a media type of the
+// VersionVendor in the Accept header, and to the requests for its path with
+// the VersionPrefix and the version, like /v2/items. The requests that do not
+// request a version are served by the DefaultVersion, or by the latest one.
+// The requests whose version has no route for the path are answered by the
+// NotAcceptable handler.
+//
+//	v1 := router.Version("1", func(config *plugo.VersionConfig) {
+//		config.Deprecated = true
+//	})
+//	v1.Get("/items", listItems)
+func (rt *Router) Version(version string, opts ...VersionOption) *Router {
+	config := &VersionConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	name := normalizeVersion(version)
+	root := rt.root()
+	if !containsString(root.versions, name) {
+		root.versions = append(root.versions, name)
+	}
+
+	group := rt.Group("")
+	group.version = &apiVersion{
+		name:   name,
+		prefix: joinPaths(rt.prefix, rt.VersionPrefix+name),
+		matcher: func(r *http.Request) bool {
+			return root.requestVersion(r) == name
+		},
+	}
+
+	if config.Deprecated || !config.Sunset.IsZero() {
+		group.Use(deprecationHeaders(config))
+	}
+
+	return group
+}
+
+// requestVersion returns the version requested by r, or the default version if
+// it does not request any.
+func (rt *Router) requestVersion(r *http.Request) string {
+	if rt.VersionHeader != "" {
+		if version := r.Header.Get(rt.VersionHeader); version != "" {
+			return normalizeVersion(version)
+		}
+	}
+
+	if rt.VersionVendor != "" {
+		prefix := "application/vnd." + strings.ToLower(rt.VersionVendor) + ".v"
+
+		for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+			mediaType, _, err := mime.ParseMediaType(accept)
+			if err != nil || !strings.HasPrefix(mediaType, prefix) {
+				continue
+			}
+
+			// the suffix of the structured syntax, like +json, is optional
+			version, _, _ := strings.Cut(mediaType[len(prefix):], "+")
+			return normalizeVersion(version)
+		}
+	}
+
+	if rt.DefaultVersion != "" {
+		return normalizeVersion(rt.DefaultVersion)
+	}
+
+	return latestVersion(rt.versions)
+}
+
+// deprecationHeaders returns a middleware that sets the Deprecation and Sunset headers of a version.
+func deprecationHeaders(config *VersionConfig) MiddlewareFunc {
+	return func(fail *error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if config.Deprecated {
+				w.Header().Set("Deprecation", "true")
+			}
+
+			if !config.Sunset.IsZero() {
+				w.Header().Set("Sunset", config.Sunset.UTC().Format(http.TimeFormat))
+			}
+		}
+	}
+}
+
+// normalizeVersion removes the spaces and the v prefix of a version.
+func normalizeVersion(version string) string {
+	version = strings.TrimSpace(version)
+	if len(version) > 1 && (version[0] == 'v' || version[0] == 'V') {
+		version = version[1:]
+	}
+
+	return version
+}
+
+// latestVersion returns the greatest of versions, comparing their dot separated
+// numbers, or an empty string if there is none.
+func latestVersion(versions []string) string {
+	var latest string
+	for _, version := range versions {
+		if latest == "" || compareVersions(version, latest) > 0 {
+			latest = version
+		}
+	}
+
+	return latest
+}
+
+// compareVersions returns -1, 0 or 1 if a is lower, equal or greater than b,
+// comparing the numbers of their dot separated parts, or the parts as text if
+// they are not numbers.
+func compareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		// missing parts are zeros, so 2 and 2.0 are equal
+		x, y := "0", "0"
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+
+		nx, errx := strconv.Atoi(x)
+		ny, erry := strconv.Atoi(y)
+		switch {
+		case errx == nil && erry == nil && nx != ny:
+			if nx < ny {
+				return -1
+			}
+			return 1
+
+		case (errx != nil || erry != nil) && x != y:
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
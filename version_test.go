@@ -0,0 +1,166 @@
+package plugo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVersion(t *testing.T) {
+	sunset := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	newRouter := func(opts ...RouterOption) *Router {
+		router := New(append([]RouterOption{func(config *RouterConfig) {
+			config.VersionVendor = "acme"
+		}}, opts...)...)
+
+		reply := func(body string) HandlerFunc {
+			return func(conn Connection) error {
+				return conn.String(http.StatusOK, body)
+			}
+		}
+
+		router.GetC("/health", reply("health"))
+
+		v1 := router.Version("v1", func(config *VersionConfig) {
+			config.Deprecated = true
+			config.Sunset = sunset
+		})
+		v1.GetC("/items", reply("v1 items")).Name("items.v1")
+
+		v2 := router.Version("2")
+		v2.GetC("/items", reply("v2 items"))
+		v2.Group("/orders").GetC("/:id", reply("v2 order"))
+
+		return router
+	}
+
+	router := newRouter()
+
+	var tests = []struct {
+		name        string
+		path        string
+		header      string
+		value       string
+		code        int
+		body        string
+		deprecation string
+	}{
+		{"latest", "/items", "", "", http.StatusOK, "v2 items", ""},
+		{"header", "/items", "Accept-Version", "1", http.StatusOK, "v1 items", "true"},
+		{"header with prefix", "/items", "Accept-Version", "v2", http.StatusOK, "v2 items", ""},
+		{"media type", "/items", "Accept", "text/html, application/vnd.acme.v1+json", http.StatusOK, "v1 items", "true"},
+		{"other vendor", "/items", "Accept", "application/vnd.other.v1+json", http.StatusOK, "v2 items", ""},
+		{"unknown version", "/items", "Accept-Version", "3", http.StatusNotAcceptable, "Not Acceptable\n", ""},
+		{"missing in version", "/orders/1", "Accept-Version", "1", http.StatusNotAcceptable, "Not Acceptable\n", ""},
+		{"group", "/orders/1", "", "", http.StatusOK, "v2 order", ""},
+		{"path", "/v1/items", "", "", http.StatusOK, "v1 items", "true"},
+		{"path over header", "/v2/items", "Accept-Version", "1", http.StatusOK, "v2 items", ""},
+		{"path group", "/v2/orders/1", "", "", http.StatusOK, "v2 order", ""},
+		{"path missing in version", "/v1/orders/1", "", "", http.StatusNotFound, "404 page not found\n", ""},
+		{"unversioned", "/health", "Accept-Version", "1", http.StatusOK, "health", ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, test.path, nil)
+		if test.header != "" {
+			r.Header.Set(test.header, test.value)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+
+		if w.Code != test.code || w.Body.String() != test.body || w.Header().Get("Deprecation") != test.deprecation {
+			t.Errorf("%s got %d '%s' '%s' want %d '%s' '%s'", test.name, w.Code, w.Body.String(), w.Header().Get("Deprecation"), test.code, test.body, test.deprecation)
+		}
+
+		if test.deprecation != "" && w.Header().Get("Sunset") != "Tue, 01 Jan 2030 00:00:00 GMT" {
+			t.Errorf("%s got sunset '%s'", test.name, w.Header().Get("Sunset"))
+		}
+	}
+
+	t.Run("url", func(t *testing.T) {
+		path, err := router.URL("items.v1")
+		if err != nil || path != "/v1/items" {
+			t.Fatalf("got '%s' %v want '/v1/items'", path, err)
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if w.Body.String() != "v1 items" {
+			t.Errorf("got '%s' want 'v1 items'", w.Body.String())
+		}
+
+		unprefixed := newRouter(func(config *RouterConfig) {
+			config.VersionPrefix = ""
+		})
+
+		if path, _ := unprefixed.URL("items.v1"); path != "/items" {
+			t.Errorf("got '%s' want '/items' without VersionPrefix", path)
+		}
+	})
+
+	t.Run("middlewares of the route", func(t *testing.T) {
+		deny := func(fail *error) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				*fail = errors.New("forbidden")
+				http.Error(w, "forbidden", http.StatusForbidden)
+			}
+		}
+
+		router := New()
+		router.Version("1").GetC("/items", func(conn Connection) error {
+			return conn.String(http.StatusOK, "v1 items")
+		}, deny)
+		router.Version("2").GetC("/items", func(conn Connection) error {
+			return conn.String(http.StatusOK, "v2 items")
+		})
+
+		for _, test := range []struct {
+			path    string
+			version string
+			code    int
+		}{
+			{"/items", "1", http.StatusForbidden},
+			{"/items", "2", http.StatusOK},
+			{"/v1/items", "", http.StatusForbidden},
+			{"/v2/items", "", http.StatusOK},
+		} {
+			r := httptest.NewRequest(http.MethodGet, test.path, nil)
+			r.Header.Set("Accept-Version", test.version)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			if w.Code != test.code {
+				t.Errorf("%s version '%s' got %d want %d", test.path, test.version, w.Code, test.code)
+			}
+		}
+	})
+
+	t.Run("default version", func(t *testing.T) {
+		router := newRouter(func(config *RouterConfig) {
+			config.DefaultVersion = "1"
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+
+		if w.Body.String() != "v1 items" {
+			t.Errorf("got '%s' want 'v1 items'", w.Body.String())
+		}
+	})
+
+	t.Run("compare versions", func(t *testing.T) {
+		if latest := latestVersion([]string{"1.10", "1.9", "2.0", "2", "beta"}); latest != "beta" {
+			t.Errorf("got latest %s want beta", latest)
+		}
+
+		if latest := latestVersion([]string{"1.10", "1.9", "2.0", "2"}); latest != "2.0" {
+			t.Errorf("got latest %s want 2.0", latest)
+		}
+	})
+}
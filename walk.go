@@ -0,0 +1,206 @@
+package plugo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// SkipRoutes can be returned by the function of Walk to stop the walk without an error.
+var SkipRoutes = errors.New("skip the remaining routes")
+
+// RouteInfo describes an endpoint registered in a Router.
+type RouteInfo struct {
+	// method of the endpoint, empty for a mounted handler that is not a Router
+	Method MethodID
+
+	// pattern of the endpoint, without optional parts, including the prefix of
+	// the mounts it is served through
+	Pattern string
+
+	// name of the route, empty if it has not been named
+	Name string
+
+	// host pattern of the Host router of the endpoint, empty for the routes of the root router
+	Host string
+
+	// API version of the endpoint if it is selected by the requested version
+	Version string
+
+	// number of middlewares executed before the handler, including the ones of
+	// the routers and mounts the request goes through
+	Middlewares int
+
+	// name of the handler function, or its type if it is not a function
+	Handler string
+}
+
+// Walk calls fn for every endpoint of the router, including the ones of its
+// Host routers and mounted routers, following the priority order of the node
+// tree with the static segments sorted. It stops at the first error returned by fn, which is returned by Walk
+// unless it is SkipRoutes.
+func (rt *Router) Walk(fn func(route RouteInfo) error) error {
+	err := rt.root().walk("", "", 0, fn)
+	if errors.Is(err, SkipRoutes) {
+		return nil
+	}
+
+	return err
+}
+
+// Routes returns the endpoints of the router in the order of Walk.
+func (rt *Router) Routes() []RouteInfo {
+	var routes []RouteInfo
+	rt.Walk(func(route RouteInfo) error {
+		routes = append(routes, route)
+		return nil
+	})
+
+	return routes
+}
+
+// PrintRoutes writes a table with the endpoints of the router to w, like:
+//
+//	METHOD  PATTERN     NAME        MIDDLEWARES  HANDLER
+//	GET     /users/:id  user.show   1            main.showUser
+func (rt *Router) PrintRoutes(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATTERN\tNAME\tMIDDLEWARES\tHANDLER")
+
+	for _, route := range rt.Routes() {
+		method := string(route.Method)
+		if method == "" {
+			method = "*"
+		}
+
+		pattern := route.Pattern
+		if route.Host != "" {
+			pattern = route.Host + pattern
+		}
+		if route.Version != "" {
+			pattern += " (version " + route.Version + ")"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", method, pattern, route.Name, route.Middlewares, route.Handler)
+	}
+
+	return tw.Flush()
+}
+
+// walk calls fn for the endpoints of the tree of rt and of its Host routers,
+// with the prefix and middlewares of the mounts they are served through.
+func (rt *Router) walk(host, prefix string, middlewares int, fn func(route RouteInfo) error) error {
+	if err := rt.routes.walk(host, prefix, middlewares, fn); err != nil {
+		return err
+	}
+
+	for _, h := range rt.hosts {
+		pattern := strings.Join(h.labels, ".")
+		if h.kind == hostWildcard {
+			pattern = "*." + pattern
+		}
+
+		if err := h.router.routes.walk(pattern, prefix, middlewares, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// walk calls fn for the endpoints of the node and its children, in priority order.
+func (nd *node) walk(host, prefix string, middlewares int, fn func(route RouteInfo) error) error {
+	methods := make([]string, 0, len(nd.endpoints))
+	for method := range nd.endpoints {
+		methods = append(methods, string(method))
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		for _, endp := range nd.endpoints[MethodID(method)] {
+			err := fn(RouteInfo{
+				Method:      endp.method,
+				Pattern:     prefix + endp.pattern,
+				Name:        endp.name,
+				Host:        host,
+				Version:     endp.version,
+				Middlewares: middlewares + len(endp.router.middlewareChain(endp.middlewares...)),
+				Handler:     handlerName(endp.source),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if mount := nd.mount; mount != nil {
+		count := middlewares + len(mount.router.middlewareChain(mount.middlewares...))
+		pattern := prefix + strings.TrimSuffix(mount.pattern, "/")
+
+		if sub, ok := mount.handler.(*Router); ok {
+			if err := sub.root().walk(host, pattern, count, fn); err != nil {
+				return err
+			}
+		} else if err := fn(RouteInfo{Pattern: pattern, Host: host, Middlewares: count, Handler: handlerName(mount.handler)}); err != nil {
+			return err
+		}
+	}
+
+	statics := append([]*node(nil), nd.statics...)
+	sort.Slice(statics, func(i, j int) bool {
+		return statics[i].label < statics[j].label
+	})
+
+	children := append(statics, nd.composites...)
+	children = append(children, nd.matchers...)
+	children = append(children, nd.typedParams...)
+	if nd.params != nil {
+		children = append(children, nd.params)
+	}
+	if nd.catchAll != nil {
+		children = append(children, nd.catchAll)
+	}
+
+	for _, child := range children {
+		if err := child.walk(host, prefix, middlewares, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// handlerName returns the name of the function of a handler without its
+// package path, like main.showUser, or the type of the handler if it is not a function.
+func handlerName(h http.Handler) string {
+	var fn any = h
+	switch h := h.(type) {
+	case *Plug:
+		fn = h.serve
+		if h.source != nil {
+			fn = h.source
+		}
+
+	case http.HandlerFunc:
+		fn = h
+	}
+
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func || value.IsNil() {
+		return fmt.Sprintf("%T", h)
+	}
+
+	f := runtime.FuncForPC(value.Pointer())
+	if f == nil {
+		return fmt.Sprintf("%T", h)
+	}
+
+	name := f.Name()
+	return name[strings.LastIndexByte(name, '/')+1:]
+}
@@ -0,0 +1,90 @@
+package plugo
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func listUsers(w http.ResponseWriter, r *http.Request) {}
+
+func showUser(conn Connection) error {
+	return nil
+}
+
+func TestWalk(t *testing.T) {
+	noop := func(fail *error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {}
+	}
+
+	router := New()
+	router.Use(noop)
+	router.Get("/users", listUsers)
+	router.GetC("/users/:id", showUser, noop).Name("user.show")
+	router.Post("/users", listUsers, noop)
+
+	sub := New()
+	sub.Get("/files/*path", listUsers)
+	router.Mount("/teams/:team", sub)
+	router.Mount("/static", http.FileServer(http.Dir(".")))
+
+	router.Host("{tenant}.example.com").Get("/", listUsers)
+
+	var want = []RouteInfo{
+		{Pattern: "/static", Middlewares: 1, Handler: "*http.fileHandler"},
+		{Method: MethodGet, Pattern: "/teams/:team/files/*path", Middlewares: 1, Handler: "plugo.listUsers"},
+		{Method: MethodGet, Pattern: "/users", Middlewares: 1, Handler: "plugo.listUsers"},
+		{Method: MethodPost, Pattern: "/users", Middlewares: 2, Handler: "plugo.listUsers"},
+		{Method: MethodGet, Pattern: "/users/:id", Name: "user.show", Middlewares: 2, Handler: "plugo.showUser"},
+		{Method: MethodGet, Pattern: "/", Host: "{tenant}.example.com", Middlewares: 1, Handler: "plugo.listUsers"},
+	}
+
+	routes := router.Routes()
+	if len(routes) != len(want) {
+		t.Fatalf("got routes %+v want %+v", routes, want)
+	}
+
+	for i := range want {
+		if routes[i] != want[i] {
+			t.Errorf("got route %+v want %+v", routes[i], want[i])
+		}
+	}
+
+	t.Run("stops walking", func(t *testing.T) {
+		var visited int
+		err := router.Walk(func(route RouteInfo) error {
+			visited++
+			return SkipRoutes
+		})
+
+		if err != nil || visited != 1 {
+			t.Errorf("got %v after %d routes", err, visited)
+		}
+
+		fail := errors.New("fail")
+		if err := router.Walk(func(route RouteInfo) error { return fail }); err != fail {
+			t.Errorf("got %v want %v", err, fail)
+		}
+	})
+
+	t.Run("print", func(t *testing.T) {
+		var out strings.Builder
+		if err := router.PrintRoutes(&out); err != nil {
+			t.Fatal(err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != len(want)+1 || !strings.HasPrefix(lines[0], "METHOD") {
+			t.Fatalf("unexpected table\n%s", out.String())
+		}
+
+		if fields := strings.Fields(lines[5]); strings.Join(fields, " ") != "GET /users/:id user.show 2 plugo.showUser" {
+			t.Errorf("got row '%s'", lines[5])
+		}
+
+		if fields := strings.Fields(lines[6]); fields[1] != "{tenant}.example.com/" {
+			t.Errorf("got row '%s'", lines[6])
+		}
+	})
+}